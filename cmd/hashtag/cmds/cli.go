@@ -2,7 +2,6 @@ package cmds
 
 import (
 	"fmt"
-	ahocorasick "github.com/BobuSumisu/aho-corasick"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/wesen/majuscule/pkg"
@@ -13,10 +12,12 @@ var ReplCmd = &cobra.Command{
 	Use:   "repl",
 	Short: "Start a REPL",
 	Run: func(cmd *cobra.Command, args []string) {
-		dicts, err := cmd.Flags().GetStringSlice("dict")
-		cobra.CheckErr(err)
+		lexicon := loadLexicon(cmd)
+		matcher := lexicon.Matcher
 
-		trie, err := pkg.BuildTrieFromFiles(dicts)
+		strategyName, err := cmd.Flags().GetString("strategy")
+		cobra.CheckErr(err)
+		strategy, err := loadScoringProfile(cmd).Strategy(strategyName)
 		cobra.CheckErr(err)
 
 		// read strings from stdin
@@ -30,26 +31,29 @@ var ReplCmd = &cobra.Command{
 
 			start := time.Now()
 			var hashTags []*pkg.HashTag
-			var trieMatches []*ahocorasick.Match
+			trieMatches := 0
 			iterCount := 1
 			for i := 0; i < iterCount; i++ {
-				trieMatches = trie.MatchString(s)
+				trieMatches = 0
+				matcher.MatchWords(s, func(pos int, word string) {
+					trieMatches++
+				})
 			}
 			elapsed := time.Since(start)
 			log.Debug().Int64("duration_ns", elapsed.Nanoseconds()).
 				Int("iterations", iterCount).
 				Str("s", s).
-				Int("trieMatches", len(trieMatches)).
-				Msg("Aho-Corasick Match")
+				Int("trieMatches", trieMatches).
+				Msg("Dictionary Match")
 
 			matchedStrings := make(map[string]interface{})
-			for _, m := range trieMatches {
+			matcher.MatchWords(s, func(pos int, word string) {
 				log.Trace().
-					Int64("pos", m.Pos()).
-					Str("match", m.MatchString()).
+					Int("pos", pos).
+					Str("match", word).
 					Msg("Match")
-				matchedStrings[string(m.Match())] = nil
-			}
+				matchedStrings[word] = nil
+			})
 
 			//// code to print out for unit tests
 			//for k := range matchedStrings {
@@ -59,9 +63,14 @@ var ReplCmd = &cobra.Command{
 			start = time.Now()
 			iterCount = 1
 			for i := 0; i < iterCount; i++ {
-				matches_ := pkg.ComputeMatches(s, trieMatches, nil)
+				matches_ := pkg.ComputeMatches(s, matcher, nil)
 				matches := pkg.NewStringMatches(s, matches_)
-				hashTags = matches.SuggestHashtags()
+				if strategy != nil {
+					// the strategies score words with their frequencies
+					matches.Rescore(strategy, lexicon.Frequency)
+				}
+				// show at most 5 results
+				hashTags = matches.SuggestTopHashtags(5)
 			}
 			elapsed = time.Since(start)
 			log.Debug().Int64("duration_ns", elapsed.Nanoseconds()).
@@ -70,13 +79,15 @@ var ReplCmd = &cobra.Command{
 				Int("hashTags", len(hashTags)).
 				Msg("SuggestHashtags")
 
-			// show at most 5 results
-			for _, hashTag := range hashTags[:5] {
-				fmt.Printf("%d - %s\n", hashTag.Words, hashTag.Tag)
+			for _, hashTag := range hashTags {
+				fmt.Printf("%v - %s\n", hashTag.Words, hashTag.Tag())
 			}
 		}
 	},
 }
 
 func init() {
+	ReplCmd.Flags().String("strategy", "", "Ranking strategy (fewest-words, max-frequency, average-score, log-probability or one of the profile)")
+	addProfileFlag(ReplCmd)
+	addLexiconFlags(ReplCmd)
 }
@@ -5,11 +5,35 @@ import (
 	"fmt"
 	"github.com/go-go-golems/glazed/pkg/cli"
 	"github.com/spf13/cobra"
+	"github.com/wesen/majuscule/pkg"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 )
 
+// readInputs returns the inputs given on the command line.
+// Arguments starting with @ are files containing one input per line.
+func readInputs(args []string) []string {
+	inputs := []string{}
+	for _, arg := range args {
+		// if arg start with @, load from file
+		if len(arg) >= 2 && arg[0] == '@' {
+			// load from file
+			s, err := os.ReadFile(arg[1:])
+			cobra.CheckErr(err)
+
+			for _, line := range strings.Split(string(s), "\n") {
+				inputs = append(inputs, line)
+			}
+		} else {
+			inputs = append(inputs, arg)
+		}
+	}
+	return inputs
+}
+
 var CompleteCmd = &cobra.Command{
 	Use:   "complete",
 	Short: "Complete one or more hashtags",
@@ -24,26 +48,49 @@ var CompleteCmd = &cobra.Command{
 		server, err := cmd.Flags().GetString("server")
 		cobra.CheckErr(err)
 
-		inputs := []string{}
-		for _, arg := range args {
-			// if arg start with @, load from file
-			if len(arg) >= 2 && arg[0] == '@' {
-				// load from file
-				s, err := os.ReadFile(arg[1:])
-				cobra.CheckErr(err)
+		context, err := cmd.Flags().GetString("context")
+		cobra.CheckErr(err)
 
-				for _, line := range strings.Split(string(s), "\n") {
-					inputs = append(inputs, line)
-				}
-			} else {
-				inputs = append(inputs, arg)
-			}
-		}
+		siblings, err := cmd.Flags().GetStringSlice("siblings")
+		cobra.CheckErr(err)
+
+		mode, err := cmd.Flags().GetString("mode")
+		cobra.CheckErr(err)
+
+		ssml, err := cmd.Flags().GetBool("ssml")
+		cobra.CheckErr(err)
+
+		styleNames, err := cmd.Flags().GetStringSlice("styles")
+		cobra.CheckErr(err)
+		styles, err := pkg.ParseStyles(styleNames)
+		cobra.CheckErr(err)
+
+		strategy, err := cmd.Flags().GetString("strategy")
+		cobra.CheckErr(err)
+
+		constraints := &Constraints{}
+		constraints.MinWordLength, err = cmd.Flags().GetInt("min-word-length")
+		cobra.CheckErr(err)
+		constraints.ShortWords, err = cmd.Flags().GetStringSlice("short-words")
+		cobra.CheckErr(err)
+		constraints.MaxWords, err = cmd.Flags().GetInt("max-words")
+		cobra.CheckErr(err)
+		constraints.Keep, err = cmd.Flags().GetStringSlice("keep")
+		cobra.CheckErr(err)
+
+		inputs := readInputs(args)
 
 		completeRequest := CompleteRequest{
-			Inputs: inputs,
-			Count:  count,
-			Debug:  debug,
+			Inputs:      inputs,
+			Count:       count,
+			Debug:       debug,
+			Context:     context,
+			Siblings:    siblings,
+			Mode:        mode,
+			SSML:        ssml,
+			Styles:      styleNames,
+			Strategy:    strategy,
+			Constraints: constraints,
 		}
 
 		bytes, err := json.Marshal(completeRequest)
@@ -67,9 +114,77 @@ var CompleteCmd = &cobra.Command{
 				obj["Input"] = response.Input
 				obj["Words"] = result.Words
 				obj["String"] = result.Tag
+				obj["Spoken"] = result.Spoken
+				if ssml {
+					obj["SSML"] = result.SSML
+				}
+				for _, style := range styles {
+					obj[string(style)] = result.Styles[string(style)]
+				}
+				err = gp.ProcessInputObject(obj)
+				cobra.CheckErr(err)
+			}
+		}
+
+		s, err := of.Output()
+		cobra.CheckErr(err)
+
+		fmt.Println(s)
+	},
+}
+
+var CheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the capitalization of one or more CamelCase hashtags",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		margin, err := cmd.Flags().GetFloat64("margin")
+		cobra.CheckErr(err)
+
+		server, err := cmd.Flags().GetString("server")
+		cobra.CheckErr(err)
+
+		checkRequest := CheckRequest{
+			Inputs: readInputs(args),
+			Margin: margin,
+		}
+
+		bytes, err := json.Marshal(checkRequest)
+		cobra.CheckErr(err)
+		res, err := http.Post(server+"/check",
+			"application/json",
+			strings.NewReader(string(bytes)))
+		cobra.CheckErr(err)
+
+		checkResponses := []CheckResponse{}
+		err = json.NewDecoder(res.Body).Decode(&checkResponses)
+		cobra.CheckErr(err)
+
+		gp, of, err := cli.SetupProcessor(cmd)
+		cobra.CheckErr(err)
+
+		for _, response := range checkResponses {
+			obj := make(map[string]interface{})
+			obj["Input"] = response.Input
+			if response.Error != "" {
+				obj["Error"] = response.Error
 				err = gp.ProcessInputObject(obj)
 				cobra.CheckErr(err)
+				continue
+			}
+
+			dubious := make([]string, len(response.Dubious))
+			for i, d := range response.Dubious {
+				dubious[i] = fmt.Sprintf("%s at %d (%s)", d.Kind, d.Pos, d.Word)
+			}
+			obj["Rank"] = fmt.Sprintf("%d/%d", response.Rank, response.Total)
+			obj["Dubious"] = strings.Join(dubious, ", ")
+			obj["Suggestion"] = ""
+			if response.Suggestion != nil {
+				obj["Suggestion"] = response.Suggestion.Tag
 			}
+			err = gp.ProcessInputObject(obj)
+			cobra.CheckErr(err)
 		}
 
 		s, err := of.Output()
@@ -79,11 +194,228 @@ var CompleteCmd = &cobra.Command{
 	},
 }
 
+var RewriteCmd = &cobra.Command{
+	Use:   "rewrite [file...]",
+	Short: "Rewrite the lowercase hashtags of a post (read from stdin or files) to CamelCase",
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+
+		server, err := cmd.Flags().GetString("server")
+		cobra.CheckErr(err)
+
+		printJSON, err := cmd.Flags().GetBool("json")
+		cobra.CheckErr(err)
+
+		var text []byte
+		if len(args) == 0 {
+			text, err = io.ReadAll(os.Stdin)
+			cobra.CheckErr(err)
+		}
+		for _, arg := range args {
+			s, err := os.ReadFile(arg)
+			cobra.CheckErr(err)
+			text = append(text, s...)
+		}
+
+		bytes, err := json.Marshal(RewriteRequest{
+			Text:   string(text),
+			Format: format,
+		})
+		cobra.CheckErr(err)
+		res, err := http.Post(server+"/rewrite",
+			"application/json",
+			strings.NewReader(string(bytes)))
+		cobra.CheckErr(err)
+
+		if res.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(res.Body)
+			cobra.CheckErr(fmt.Errorf("server returned %s: %s", res.Status, body))
+		}
+
+		response := RewriteResponse{}
+		err = json.NewDecoder(res.Body).Decode(&response)
+		cobra.CheckErr(err)
+
+		if printJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			cobra.CheckErr(encoder.Encode(response))
+			return
+		}
+
+		fmt.Print(response.Text)
+	},
+}
+
+var LatticeCmd = &cobra.Command{
+	Use:   "lattice <input>",
+	Short: "Render the word lattice of a hashtag as Graphviz DOT or SVG",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		server, err := cmd.Flags().GetString("server")
+		cobra.CheckErr(err)
+
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+
+		top, err := cmd.Flags().GetInt("top")
+		cobra.CheckErr(err)
+
+		context, err := cmd.Flags().GetString("context")
+		cobra.CheckErr(err)
+
+		query := url.Values{}
+		query.Set("input", args[0])
+		query.Set("format", format)
+		query.Set("top", fmt.Sprintf("%d", top))
+		query.Set("context", context)
+		res, err := http.Get(server + "/lattice?" + query.Encode())
+		cobra.CheckErr(err)
+
+		body, err := io.ReadAll(res.Body)
+		cobra.CheckErr(err)
+		if res.StatusCode != http.StatusOK {
+			cobra.CheckErr(fmt.Errorf("server returned %s: %s", res.Status, body))
+		}
+
+		fmt.Print(string(body))
+	},
+}
+
+// wordsRequest calls an /admin/words endpoint of the server and outputs the changes
+// of the dictionary it returns.
+func wordsRequest(cmd *cobra.Command, path string, req *DictionaryRequest) {
+	server, err := cmd.Flags().GetString("server")
+	cobra.CheckErr(err)
+	adminToken, err := cmd.Flags().GetString("admin-token")
+	cobra.CheckErr(err)
+
+	method := http.MethodGet
+	var body io.Reader
+	if req != nil {
+		bytes, err := json.Marshal(req)
+		cobra.CheckErr(err)
+		method = http.MethodPost
+		body = strings.NewReader(string(bytes))
+	}
+	httpReq, err := http.NewRequest(method, server+"/admin/words"+path, body)
+	cobra.CheckErr(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if adminToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+adminToken)
+	}
+
+	res, err := http.DefaultClient.Do(httpReq)
+	cobra.CheckErr(err)
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		cobra.CheckErr(fmt.Errorf("server returned %s: %s", res.Status, body))
+	}
+
+	response := DictionaryResponse{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	cobra.CheckErr(err)
+
+	gp, of, err := cli.SetupProcessor(cmd)
+	cobra.CheckErr(err)
+	for _, e := range response.Entries {
+		obj := make(map[string]interface{})
+		obj["Word"] = e.Word
+		obj["Status"] = e.Status
+		obj["Frequency"] = e.Frequency
+		err = gp.ProcessInputObject(obj)
+		cobra.CheckErr(err)
+	}
+
+	s, err := of.Output()
+	cobra.CheckErr(err)
+
+	fmt.Println(s)
+}
+
+var WordsCmd = &cobra.Command{
+	Use:   "words",
+	Short: "Change the dictionary of a running server",
+}
+
+var wordsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the words added, removed and reweighted since the server loaded its dictionary",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		wordsRequest(cmd, "", nil)
+	},
+}
+
+var wordsAddCmd = &cobra.Command{
+	Use:   "add <word>...",
+	Short: "Add words to the dictionary",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		frequency, err := cmd.Flags().GetInt("frequency")
+		cobra.CheckErr(err)
+		wordsRequest(cmd, "/add", &DictionaryRequest{Words: readInputs(args), Frequency: frequency})
+	},
+}
+
+var wordsRemoveCmd = &cobra.Command{
+	Use:   "remove <word>...",
+	Short: "Remove words from the dictionary",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		wordsRequest(cmd, "/remove", &DictionaryRequest{Words: readInputs(args)})
+	},
+}
+
+var wordsReweightCmd = &cobra.Command{
+	Use:   "reweight <word>...",
+	Short: "Change the frequency of words",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		frequency, err := cmd.Flags().GetInt("frequency")
+		cobra.CheckErr(err)
+		wordsRequest(cmd, "/reweight", &DictionaryRequest{Words: readInputs(args), Frequency: frequency})
+	},
+}
+
 func init() {
 	CompleteCmd.Flags().String("server", "http://localhost:3333", "Server to use")
 	CompleteCmd.Flags().Int("count", 5, "Number of results to return")
 	CompleteCmd.Flags().Bool("debug", false, "Enable debug output")
+	CompleteCmd.Flags().String("context", "", "Text surrounding the hashtags, used to rank the suggestions")
+	CompleteCmd.Flags().StringSlice("siblings", []string{}, "Other hashtags of the post, used to rank the suggestions")
+	CompleteCmd.Flags().String("mode", "hashtag", "Segmentation mode (hashtag, handle, domain)")
+	CompleteCmd.Flags().Bool("ssml", false, "Also render the suggestions as SSML, for speech synthesizers")
+	CompleteCmd.Flags().StringSlice("styles", []string{}, "Rendering styles to output (camel, lower-camel, snake, kebab, spaced, lower, all)")
+	CompleteCmd.Flags().Int("min-word-length", 0, "Do not use words shorter than this (0 means no minimum)")
+	CompleteCmd.Flags().StringSlice("short-words", []string{}, "Words allowed even if shorter than --min-word-length, such as a,i")
+	CompleteCmd.Flags().Int("max-words", 0, "Maximum number of words of a hashtag (0 means no limit)")
+	CompleteCmd.Flags().StringSlice("keep", []string{}, "Words never to split")
+	CompleteCmd.Flags().String("strategy", "", "Ranking strategy (fewest-words, max-frequency, average-score, log-probability or one of the server profile)")
 
 	flagDefaults := cli.NewFlagsDefaults()
 	cli.AddFlags(CompleteCmd, flagDefaults)
+
+	CheckCmd.Flags().String("server", "http://localhost:3333", "Server to use")
+	CheckCmd.Flags().Float64("margin", pkg.DefaultCheckMargin, "Ratio by which the best reading has to beat the given one to be suggested")
+	cli.AddFlags(CheckCmd, flagDefaults)
+
+	RewriteCmd.Flags().String("server", "http://localhost:3333", "Server to use")
+	RewriteCmd.Flags().String("format", "text", "Format of the input (text, markdown, html)")
+	RewriteCmd.Flags().Bool("json", false, "Print the full response, including the offsets of the rewritten hashtags")
+
+	LatticeCmd.Flags().String("server", "http://localhost:3333", "Server to use")
+	LatticeCmd.Flags().String("format", "svg", "Output format (dot, svg)")
+	LatticeCmd.Flags().Int("top", 5, "Number of best paths to highlight")
+	LatticeCmd.Flags().String("context", "", "Text surrounding the hashtag, used to rank the paths")
+
+	WordsCmd.PersistentFlags().String("server", "http://localhost:3333", "Server to use")
+	WordsCmd.PersistentFlags().String("admin-token", "", "Bearer token of the admin endpoints of the server")
+	wordsAddCmd.Flags().Int("frequency", 0, "Frequency per million of the added words (0 to only score them by length)")
+	wordsReweightCmd.Flags().Int("frequency", 0, "New frequency per million of the words")
+	for _, c := range []*cobra.Command{wordsListCmd, wordsAddCmd, wordsRemoveCmd, wordsReweightCmd} {
+		cli.AddFlags(c, flagDefaults)
+		WordsCmd.AddCommand(c)
+	}
 }
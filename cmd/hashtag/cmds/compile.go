@@ -0,0 +1,51 @@
+package cmds
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/wesen/majuscule/pkg"
+	"time"
+)
+
+var CompileCmd = &cobra.Command{
+	Use:   "compile",
+	Short: "Compile the dictionaries and the frequency file into a lexicon snapshot that loads in milliseconds",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, err := cmd.Flags().GetString("output")
+		cobra.CheckErr(err)
+
+		check, err := cmd.Flags().GetBool("check")
+		cobra.CheckErr(err)
+		if check {
+			start := time.Now()
+			lexicon, err := pkg.OpenLexiconSnapshot(output, true)
+			cobra.CheckErr(err)
+			log.Info().Dur("duration", time.Since(start)).Msg("Verified the lexicon snapshot")
+			fmt.Printf("%s: version %s, %d words\n", output, lexicon.Version, lexicon.Words)
+			return
+		}
+
+		dicts, err := cmd.Flags().GetStringSlice("dict")
+		cobra.CheckErr(err)
+		frequencyPath, err := cmd.Flags().GetString("frequency")
+		cobra.CheckErr(err)
+
+		lexicon, err := pkg.LoadLexicon(dicts, frequencyPath, getMatcherKind(cmd))
+		cobra.CheckErr(err)
+
+		start := time.Now()
+		checksum, err := pkg.WriteLexiconSnapshot(output, lexicon)
+		cobra.CheckErr(err)
+		log.Info().Dur("duration", time.Since(start)).Msg("Wrote the lexicon snapshot")
+
+		fmt.Printf("%s: version %s, %d words, checksum %s\n", output, checksum[:12], lexicon.Words, checksum)
+	},
+}
+
+func init() {
+	CompileCmd.Flags().StringP("output", "o", "lexicon.snapshot", "Snapshot file to write")
+	CompileCmd.Flags().Bool("check", false, "Verify the checksum of an existing snapshot instead of compiling one")
+	addMatcherFlag(CompileCmd)
+}
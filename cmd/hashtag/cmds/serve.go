@@ -1,114 +1,622 @@
 package cmds
 
 import (
+	"crypto/subtle"
 	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
-	ahocorasick "github.com/BobuSumisu/aho-corasick"
 	"github.com/gin-gonic/contrib/static"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/wesen/majuscule/pkg"
+	grpc2 "github.com/wesen/majuscule/pkg/grpc"
+	api "github.com/wesen/majuscule/pkg/grpc/api"
+	"google.golang.org/grpc"
 	"io/fs"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
 type Server struct {
-	trie      *ahocorasick.Trie
+	completer *pkg.Completer
+	reloader  *pkg.LexiconReloader
+	sessions  *pkg.RefineSessions
 	port      string
-	frequency map[string]int
+	// adminToken is the bearer token of the /admin endpoints, which are disabled if it is empty
+	adminToken string
+	// batchLimits bound the work of a POST /complete request
+	batchLimits pkg.BatchLimits
+	// overrides are the curated segmentations of the completer
+	overrides *pkg.OverrideReranker
 }
 
 type AhoCorasickMatch struct {
-	Pos   int     `json:"pos"`
+	Pos    int     `json:"pos"`
+	Word   string  `json:"word"`
+	Score  float64 `json:"score"`
+	Source string  `json:"source,omitempty"`
+}
+
+type HashTag struct {
+	Tag        string            `json:"tag"`
+	Score      float64           `json:"score"`
+	Words      []string          `json:"words"`
+	Scores     []float64         `json:"scores"`
+	Separators []string          `json:"separators,omitempty"`
+	Spoken     string            `json:"spoken"`
+	SSML       string            `json:"ssml,omitempty"`
+	Styles     map[string]string `json:"styles,omitempty"`
+	Spans      []*WordSpan       `json:"spans,omitempty"`
+	Rerankings []*Reranking      `json:"rerankings,omitempty"`
+}
+
+// Reranking documents how a re-ranker multiplied the score of a suggestion
+type Reranking struct {
+	Reranker string  `json:"reranker"`
+	Factor   float64 `json:"factor"`
+	Reason   string  `json:"reason"`
+}
+
+// WordSpan is the position of a word in the input, in bytes and in characters
+type WordSpan struct {
+	Word      string `json:"word"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	CharStart int    `json:"char_start"`
+	CharEnd   int    `json:"char_end"`
+}
+
+// ContextAdjustment documents how the context of a request changed the score of a word
+// or a bigram.
+type ContextAdjustment struct {
+	Pos    int     `json:"pos"`
+	Word   string  `json:"word"`
+	Kind   string  `json:"kind"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// LatticeEdge is a word going from the byte position From to To
+type LatticeEdge struct {
+	From  int     `json:"from"`
+	To    int     `json:"to"`
 	Word  string  `json:"word"`
 	Score float64 `json:"score"`
 }
 
-type HashTag struct {
-	Tag    string    `json:"tag"`
-	Score  float64   `json:"score"`
-	Words  []string  `json:"words"`
-	Scores []float64 `json:"scores"`
+type Lattice struct {
+	Nodes []int          `json:"nodes"`
+	Edges []*LatticeEdge `json:"edges"`
 }
 
 type CompleteResponse struct {
-	Input              string              `json:"input"`
-	Count              int                 `json:"count"`
-	Hashtags           []*HashTag          `json:"hashtags"`
-	Matches            []*AhoCorasickMatch `json:"matches,omitempty"`
-	MatchDuration_ns   int64               `json:"match_duration_ns"`
-	SuggestDuration_ns int64               `json:"suggest_duration_ns"`
+	Input              string               `json:"input"`
+	Count              int                  `json:"count"`
+	Domain             string               `json:"domain,omitempty"`
+	Hashtags           []*HashTag           `json:"hashtags"`
+	Matches            []*AhoCorasickMatch  `json:"matches,omitempty"`
+	Adjustments        []*ContextAdjustment `json:"adjustments,omitempty"`
+	Lattice            *Lattice             `json:"lattice,omitempty"`
+	MatchDuration_ns   int64                `json:"match_duration_ns"`
+	SuggestDuration_ns int64                `json:"suggest_duration_ns"`
+	// LexiconVersion identifies the dictionary and frequency files the suggestions come from
+	LexiconVersion string `json:"lexicon_version,omitempty"`
 }
 
 type CompleteResponses []CompleteResponse
 
+// TypeRequest is a message of the /type websocket, sent with the whole input on every keystroke
+type TypeRequest struct {
+	// ID is echoed in the response, so that clients can drop the responses to stale inputs
+	ID    int    `json:"id"`
+	Input string `json:"input"`
+}
+
+// TypeResponse holds the suggestions of a TypeRequest
+type TypeResponse struct {
+	ID int `json:"id"`
+	// Reused is the number of bytes at the start of the input whose segmentations were
+	// reused from the previous request
+	Reused int `json:"reused"`
+	// Error is set instead of the suggestions if the request could not be parsed
+	Error string `json:"error,omitempty"`
+	*CompleteResponse
+}
+
 type CompleteRequest struct {
 	Inputs []string `json:"inputs"`
-	Count  int      `json:"count"`
-	Debug  bool     `json:"debug"`
+	// Count is the number of suggestions returned for each input, 5 if omitted
+	Count int  `json:"count"`
+	Debug bool `json:"debug"`
+	// Context is the text surrounding the hashtags, for example the post they appear in
+	Context string `json:"context,omitempty"`
+	// Siblings are the other hashtags of the post
+	Siblings []string `json:"siblings,omitempty"`
+	// Mode is either hashtag (the default), handle or domain
+	Mode string `json:"mode,omitempty"`
+	// SSML also renders each suggestion as SSML
+	SSML bool `json:"ssml,omitempty"`
+	// Styles are the rendering styles to return for each suggestion
+	Styles []string `json:"styles,omitempty"`
+	// Lattice also returns the pruned word lattice of each hashtag
+	Lattice bool `json:"lattice,omitempty"`
+	// Strategy is the ranking strategy of the scoring profile, its default if empty
+	Strategy string `json:"strategy,omitempty"`
+	// Constraints restrict the segmentations
+	Constraints *Constraints `json:"constraints,omitempty"`
+	// User adds the words of the personal dictionary of the user
+	User string `json:"user,omitempty"`
 }
 
-func (s *Server) computeHashtags(input string, count int) CompleteResponse {
-	results := CompleteResponse{
-		Input:    input,
-		Count:    count,
-		Hashtags: make([]*HashTag, 0),
-		Matches:  make([]*AhoCorasickMatch, 0),
+// Constraints restrict the segmentations of a complete request, see pkg.Constraints
+type Constraints struct {
+	MinWordLength int      `json:"min_word_length,omitempty"`
+	ShortWords    []string `json:"short_words,omitempty"`
+	MaxWords      int      `json:"max_words,omitempty"`
+	Keep          []string `json:"keep,omitempty"`
+}
+
+func (c *Constraints) toPkg() *pkg.Constraints {
+	if c == nil {
+		return nil
+	}
+	return &pkg.Constraints{
+		MinWordLength: c.MinWordLength,
+		ShortWords:    c.ShortWords,
+		MaxWords:      c.MaxWords,
+		Keep:          c.Keep,
 	}
+}
+
+type HandleVariant struct {
+	Handle    string   `json:"handle"`
+	Words     []string `json:"words"`
+	Ambiguous bool     `json:"ambiguous"`
+}
+
+type HandleVariantsResponse struct {
+	Name     string           `json:"name"`
+	Variants []*HandleVariant `json:"variants"`
+}
+
+type RefineRequest struct {
+	Session  string `json:"session"`
+	Input    string `json:"input"`
+	Word     string `json:"word"`
+	Boundary int    `json:"boundary"`
+	Count    int    `json:"count"`
+}
+
+type RefineOption struct {
+	Word  string   `json:"word"`
+	Score float64  `json:"score"`
+	Best  *HashTag `json:"best"`
+}
+
+type RefineResponse struct {
+	Session   string          `json:"session"`
+	Input     string          `json:"input"`
+	Words     []string        `json:"words"`
+	Pos       int             `json:"pos"`
+	Remaining string          `json:"remaining"`
+	Done      bool            `json:"done"`
+	Options   []*RefineOption `json:"options"`
+	Hashtags  []*HashTag      `json:"hashtags"`
+}
+
+// SelectRequest records the suggestion a user chose for an input, given either
+// as its words or as a CamelCase tag
+type SelectRequest struct {
+	Input string   `json:"input"`
+	Words []string `json:"words,omitempty"`
+	Tag   string   `json:"tag,omitempty"`
+}
+
+type SelectResponse struct {
+	Input string   `json:"input"`
+	Words []string `json:"words"`
+	// Count is the number of times the words were selected for the input
+	Count int `json:"count"`
+}
+
+type ReloadResponse struct {
+	Version string `json:"version"`
+	// Previous is the version of the lexicon before the reload
+	Previous string `json:"previous"`
+	Words    int    `json:"words"`
+}
+
+// CacheResponse holds the counters of the result cache, which are all 0 if it is disabled
+type CacheResponse struct {
+	Entries  int   `json:"entries"`
+	Capacity int   `json:"capacity"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+	// Shared is the number of misses that waited for an identical request being computed
+	Shared    int64 `json:"shared"`
+	Evictions int64 `json:"evictions"`
+	// Purges is the number of times the cache was emptied, by a change of the lexicon or a DELETE
+	Purges int64 `json:"purges"`
+}
 
-	// cheap ass limiting
-	if len(input) > 60 {
-		return results
+func NewCacheResponse(stats pkg.CacheStats) CacheResponse {
+	return CacheResponse{
+		Entries:   stats.Entries,
+		Capacity:  stats.Capacity,
+		Hits:      stats.Hits,
+		Misses:    stats.Misses,
+		Shared:    stats.Shared,
+		Evictions: stats.Evictions,
+		Purges:    stats.Purges,
 	}
+}
 
-	start := time.Now()
+// DictionaryRequest adds, removes or reweights words of the dictionary
+type DictionaryRequest struct {
+	Words []string `json:"words"`
+	// Frequency is the frequency per million of the added or reweighted words
+	Frequency int `json:"frequency,omitempty"`
+}
+
+// OverrideRequest sets the curated segmentation of an input, given either as its words
+// or as a CamelCase tag. Only the input is needed to remove it.
+type OverrideRequest struct {
+	Input string   `json:"input"`
+	Words []string `json:"words,omitempty"`
+	Tag   string   `json:"tag,omitempty"`
+}
+
+// OverridesResponse holds the curated segmentations, by lowercase input
+type OverridesResponse struct {
+	Overrides map[string][]string `json:"overrides"`
+}
+
+// UserWordsRequest adds or removes words of the personal dictionary of a user
+type UserWordsRequest struct {
+	Words []string `json:"words"`
+}
+
+type UserWordsResponse struct {
+	User  string   `json:"user"`
+	Words []string `json:"words"`
+}
+
+// DictionaryEntry is a change made to the dictionary since the dictionary files were loaded
+type DictionaryEntry struct {
+	Word      string `json:"word"`
+	Status    string `json:"status"`
+	Frequency int    `json:"frequency,omitempty"`
+}
 
-	trieMatches := s.trie.MatchString(input)
-	matches_ := pkg.ComputeMatches(input, trieMatches, s.frequency)
-	elapsed := time.Since(start)
+type DictionaryResponse struct {
+	Entries []*DictionaryEntry `json:"entries"`
+}
+
+func NewDictionaryResponse(entries []pkg.DictionaryEntry) DictionaryResponse {
+	ret := DictionaryResponse{Entries: make([]*DictionaryEntry, len(entries))}
+	for i, e := range entries {
+		ret.Entries[i] = &DictionaryEntry{
+			Word:      e.Word,
+			Status:    string(e.Status),
+			Frequency: e.Frequency,
+		}
+	}
+	return ret
+}
+
+type CheckRequest struct {
+	Inputs []string `json:"inputs"`
+	Margin float64  `json:"margin"`
+}
+
+type DubiousBoundary struct {
+	Pos  int    `json:"pos"`
+	Kind string `json:"kind"`
+	Word string `json:"word"`
+}
+
+type CheckResponse struct {
+	Input      string             `json:"input"`
+	Hashtag    *HashTag           `json:"hashtag,omitempty"`
+	Rank       int                `json:"rank"`
+	Total      int                `json:"total"`
+	Dubious    []*DubiousBoundary `json:"dubious"`
+	Suggestion *HashTag           `json:"suggestion,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+type RewriteRequest struct {
+	Text   string `json:"text"`
+	Format string `json:"format"`
+}
+
+type HashTagRewrite struct {
+	Original       string `json:"original"`
+	Rewritten      string `json:"rewritten"`
+	Start          int    `json:"start"`
+	End            int    `json:"end"`
+	RewrittenStart int    `json:"rewritten_start"`
+	RewrittenEnd   int    `json:"rewritten_end"`
+}
+
+type RewriteResponse struct {
+	Text     string            `json:"text"`
+	Format   string            `json:"format"`
+	Rewrites []*HashTagRewrite `json:"rewrites"`
+}
+
+func NewHashTag(h *pkg.HashTag) *HashTag {
+	return &HashTag{
+		Tag:        h.Tag(),
+		Score:      h.Score(),
+		Words:      h.Words,
+		Scores:     h.Scores,
+		Separators: h.Separators,
+		Spoken:     h.Spoken(),
+		Rerankings: NewRerankings(h.Rerankings),
+	}
+}
+
+func NewRerankings(rerankings []*pkg.Reranking) []*Reranking {
+	if len(rerankings) == 0 {
+		return nil
+	}
+	ret := make([]*Reranking, len(rerankings))
+	for i, r := range rerankings {
+		ret[i] = &Reranking{
+			Reranker: r.Reranker,
+			Factor:   r.Factor,
+			Reason:   r.Reason,
+		}
+	}
+	return ret
+}
 
-	log.Debug().Int64("duration_ns", elapsed.Nanoseconds()).
+func NewWordSpans(spans []*pkg.Span) []*WordSpan {
+	ret := make([]*WordSpan, len(spans))
+	for i, s := range spans {
+		ret[i] = &WordSpan{
+			Word:      s.Word,
+			Start:     s.Start,
+			End:       s.End,
+			CharStart: s.CharStart,
+			CharEnd:   s.CharEnd,
+		}
+	}
+	return ret
+}
+
+// renderOptions are the optional renderings of the suggestions of a complete request.
+type renderOptions struct {
+	ssml   bool
+	styles []pkg.Style
+	// casing is the canonical casing of the lexicon the suggestions were computed with
+	casing map[string]string
+}
+
+func (o renderOptions) newHashTag(h *pkg.HashTag) *HashTag {
+	ret := NewHashTag(h)
+	if o.ssml {
+		ret.SSML = h.SSML()
+	}
+	if len(o.styles) > 0 {
+		ret.Styles = make(map[string]string, len(o.styles))
+		for style, s := range h.Styles(o.styles, o.casing) {
+			ret.Styles[string(style)] = s
+		}
+	}
+	return ret
+}
+
+func (s *Server) computeHashtags(input string, render renderOptions, options pkg.SuggestOptions) CompleteResponse {
+	suggestions := s.completer.Suggest(input, options)
+
+	log.Debug().Int64("duration_ns", suggestions.MatchDuration.Nanoseconds()).
 		Str("input", input).
-		Int("trieMatches", len(trieMatches)).
+		Str("mode", string(options.Mode)).
 		Msg("Match")
 
-	for _, m := range matches_ {
-		for _, w := range m {
-			results.Matches = append(results.Matches, &AhoCorasickMatch{
-				Pos:   w.Pos,
-				Word:  w.Match,
-				Score: w.Score,
-			})
+	return render.newCompleteResponse(suggestions, options.Count)
+}
+
+// newCompleteResponse renders the suggestions of a complete request.
+func (o renderOptions) newCompleteResponse(suggestions *pkg.Suggestions, count int) CompleteResponse {
+	input := suggestions.Input
+	results := CompleteResponse{
+		Input:              input,
+		Count:              count,
+		Domain:             suggestions.Domain,
+		Hashtags:           make([]*HashTag, 0),
+		Matches:            make([]*AhoCorasickMatch, 0),
+		Adjustments:        make([]*ContextAdjustment, 0),
+		MatchDuration_ns:   suggestions.MatchDuration.Nanoseconds(),
+		SuggestDuration_ns: suggestions.SuggestDuration.Nanoseconds(),
+		LexiconVersion:     suggestions.LexiconVersion,
+	}
+
+	for _, m := range suggestions.Matches {
+		results.Matches = append(results.Matches, &AhoCorasickMatch{
+			Pos:    m.Pos,
+			Word:   m.Match,
+			Score:  m.Score,
+			Source: m.Source,
+		})
+	}
+
+	for _, a := range suggestions.Adjustments {
+		results.Adjustments = append(results.Adjustments, &ContextAdjustment{
+			Pos:    a.Pos,
+			Word:   a.Word,
+			Kind:   string(a.Kind),
+			Before: a.Before,
+			After:  a.After,
+		})
+	}
+
+	if suggestions.Lattice != nil {
+		results.Lattice = &Lattice{
+			Nodes: suggestions.Lattice.Nodes,
+			Edges: make([]*LatticeEdge, len(suggestions.Lattice.Edges)),
+		}
+		for i, e := range suggestions.Lattice.Edges {
+			results.Lattice.Edges[i] = &LatticeEdge{
+				From:  e.From,
+				To:    e.To,
+				Word:  e.Word,
+				Score: e.Score,
+			}
 		}
 	}
 
-	//// code to print out for unit tests
-	//for k := range matchedStrings {
-	//	fmt.Printf("\"%s\",\n", k)
-	//}
+	for _, h := range suggestions.HashTags {
+		hashtag := o.newHashTag(h)
+		hashtag.Spans = NewWordSpans(h.Spans(input, suggestions.Offset))
+		results.Hashtags = append(results.Hashtags, hashtag)
+	}
+
+	return results
+}
+
+// maxTypeMessageSize is the largest message a /type websocket accepts
+const maxTypeMessageSize = 64 * 1024
+
+var typeUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// typeHashtags upgrades GET /type to a websocket over which a client sends its input as
+// it is typed. The options of the session are the query parameters of GET /complete, and
+// each input is segmented incrementally from the previous one, see pkg.TypingSession.
+func (s *Server) typeHashtags(c *gin.Context) {
+	query, err := s.parseCompleteQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := typeUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		log.Debug().Err(err).Msg("Could not upgrade /type")
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(maxTypeMessageSize)
 
-	start = time.Now()
-	matches := pkg.NewStringMatches(input, matches_)
-	hashTags := matches.SuggestHashtags()
+	session := s.completer.NewTypingSession(query.options)
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug().Err(err).Msg("Closing /type")
+			}
+			return
+		}
 
-	for i, h := range hashTags {
-		if i > count {
-			break
+		var req TypeRequest
+		err = json.Unmarshal(message, &req)
+		if err != nil {
+			err = conn.WriteJSON(TypeResponse{Error: "Invalid request"})
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		suggestions := session.Update(req.Input)
+		results := query.render.newCompleteResponse(suggestions, query.options.Count)
+		response := TypeResponse{
+			ID:               req.ID,
+			Reused:           session.Reused(),
+			CompleteResponse: &results,
+		}
+		if !query.debug {
+			response.Matches = nil
+			response.Adjustments = nil
+		}
+		err = conn.WriteJSON(response)
+		if err != nil {
+			log.Debug().Err(err).Msg("Closing /type")
+			return
 		}
-		results.Hashtags = append(results.Hashtags, &HashTag{
-			Tag:    h.Tag(),
-			Score:  h.Score(),
-			Words:  h.Words,
-			Scores: h.Scores,
+	}
+}
+
+func (s *Server) refine(req RefineRequest) (RefineResponse, error) {
+	session, err := s.sessions.Refine(s.completer, req.Session, req.Input, req.Word, req.Boundary)
+	if err != nil {
+		return RefineResponse{}, err
+	}
+
+	state := session.State()
+	response := RefineResponse{
+		Session:   session.Token,
+		Input:     session.Matches.String,
+		Words:     state.Words,
+		Pos:       state.Pos,
+		Remaining: state.Remaining,
+		Done:      state.Done,
+		Options:   make([]*RefineOption, 0),
+		Hashtags:  make([]*HashTag, 0),
+	}
+	for _, o := range session.NextWords(req.Count) {
+		best := NewHashTag(o.Best)
+		best.Spans = NewWordSpans(o.Best.Spans(response.Input, 0))
+		response.Options = append(response.Options, &RefineOption{
+			Word:  o.Word,
+			Score: o.Score,
+			Best:  best,
 		})
 	}
+	for _, h := range session.HashTags(req.Count) {
+		hashtag := NewHashTag(h)
+		hashtag.Spans = NewWordSpans(h.Spans(response.Input, 0))
+		response.Hashtags = append(response.Hashtags, hashtag)
+	}
 
-	elapsed = time.Since(start)
-	results.SuggestDuration_ns = elapsed.Nanoseconds()
+	return response, nil
+}
 
-	return results
+func (s *Server) check(input string, margin float64) CheckResponse {
+	response := CheckResponse{
+		Input:   input,
+		Dubious: make([]*DubiousBoundary, 0),
+	}
+
+	result, err := s.completer.Check(input, margin)
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	// the hashtag is checked without its leading #
+	offset := len(input) - len(strings.TrimPrefix(input, "#"))
+	response.Hashtag = NewHashTag(result.HashTag)
+	response.Hashtag.Spans = NewWordSpans(result.HashTag.Spans(input, offset))
+	response.Rank = result.Rank
+	response.Total = result.Total
+	for _, d := range result.Dubious {
+		response.Dubious = append(response.Dubious, &DubiousBoundary{
+			Pos:  d.Pos,
+			Kind: string(d.Kind),
+			Word: d.Word,
+		})
+	}
+	if result.Suggestion != nil {
+		response.Suggestion = NewHashTag(result.Suggestion)
+		response.Suggestion.Spans = NewWordSpans(result.Suggestion.Spans(input, offset))
+	}
+
+	return response
 }
 
 //go:embed web/*
@@ -145,29 +653,101 @@ func EmbedFolder(fsEmbed embed.FS, targetPath string, index bool) static.ServeFi
 	}
 }
 
+// completeQuery holds the options of a complete request given as query parameters.
+type completeQuery struct {
+	render  renderOptions
+	options pkg.SuggestOptions
+	debug   bool
+}
+
+// parseCompleteQuery parses the query parameters of GET /complete, which are also the
+// options of the typing sessions of GET /type.
+func (s *Server) parseCompleteQuery(c *gin.Context) (completeQuery, error) {
+	countString := c.DefaultQuery("count", "5")
+	count := 5
+	_, err := fmt.Sscanf(countString, "%d", &count)
+	if err != nil || count <= 0 {
+		return completeQuery{}, fmt.Errorf("Invalid count")
+	}
+
+	mode, err := pkg.ParseMode(c.Query("mode"))
+	if err != nil {
+		return completeQuery{}, err
+	}
+
+	styles, err := pkg.ParseStyles(c.QueryArray("styles"))
+	if err != nil {
+		return completeQuery{}, err
+	}
+
+	strategy, err := s.completer.Strategy(c.Query("strategy"))
+	if err != nil {
+		return completeQuery{}, err
+	}
+
+	constraints := &Constraints{
+		ShortWords: c.QueryArray("short_words"),
+		Keep:       c.QueryArray("keep"),
+	}
+	_, err = fmt.Sscanf(c.DefaultQuery("min_word_length", "0"), "%d", &constraints.MinWordLength)
+	if err != nil {
+		return completeQuery{}, fmt.Errorf("Invalid min_word_length")
+	}
+	_, err = fmt.Sscanf(c.DefaultQuery("max_words", "0"), "%d", &constraints.MaxWords)
+	if err != nil {
+		return completeQuery{}, fmt.Errorf("Invalid max_words")
+	}
+
+	return completeQuery{
+		render: renderOptions{ssml: c.DefaultQuery("ssml", "false") == "true", styles: styles, casing: s.completer.Casing()},
+		options: pkg.SuggestOptions{
+			Mode:        mode,
+			Count:       count,
+			Context:     s.completer.NewContext(c.Query("context"), c.QueryArray("siblings")),
+			Lattice:     c.DefaultQuery("lattice", "false") == "true",
+			Strategy:    strategy,
+			Constraints: constraints.toPkg(),
+			User:        c.Query("user"),
+		},
+		debug: c.DefaultQuery("debug", "false") == "true",
+	}, nil
+}
+
 func (s *Server) Run() error {
+	router := s.router()
+	addr := ":" + s.port
+	log.Info().Str("port", s.port).Msg("Starting server")
+	return router.Run(addr)
+}
+
+// router registers the endpoints of the server. The /admin endpoints are only registered
+// if the server has an admin token.
+func (s *Server) router() *gin.Engine {
 	router := gin.Default()
 
+	router.Use(func(c *gin.Context) {
+		c.Header("X-Lexicon-Version", s.completer.Dictionary.Version())
+	})
+
 	router.GET("/complete", func(c *gin.Context) {
-		countString := c.DefaultQuery("count", "5")
-		count := 5
-		_, err := fmt.Sscanf(countString, "%d", &count)
+		query, err := s.parseCompleteQuery(c)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		debug := c.DefaultQuery("debug", "false")
-
 		input := c.Query("input")
-		response := s.computeHashtags(input, count)
+		response := s.computeHashtags(input, query.render, query.options)
 
-		if debug != "true" {
+		if !query.debug {
 			response.Matches = nil
+			response.Adjustments = nil
 		}
 		c.JSON(http.StatusOK, response)
 	})
 
+	router.GET("/type", s.typeHashtags)
+
 	router.POST("/complete", func(c *gin.Context) {
 		var req CompleteRequest
 		err := c.BindJSON(&req)
@@ -175,27 +755,564 @@ func (s *Server) Run() error {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 			return
 		}
+		if req.Count == 0 {
+			req.Count = 5
+		}
+		if req.Count < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count"})
+			return
+		}
 
-		responses := make([]CompleteResponse, len(req.Inputs))
-		for i, input := range req.Inputs {
-			responses[i] = s.computeHashtags(input, req.Count)
+		mode, err := pkg.ParseMode(req.Mode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		styles, err := pkg.ParseStyles(req.Styles)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		strategy, err := s.completer.Strategy(req.Strategy)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		render := renderOptions{ssml: req.SSML, styles: styles, casing: s.completer.Casing()}
+		options := pkg.SuggestOptions{
+			Mode:        mode,
+			Count:       req.Count,
+			Context:     s.completer.NewContext(req.Context, req.Siblings),
+			Lattice:     req.Lattice,
+			Strategy:    strategy,
+			Constraints: req.Constraints.toPkg(),
+			User:        req.User,
+		}
+		suggestions, err := s.completer.SuggestBatch(c.Request.Context(), req.Inputs, options, s.batchLimits)
+		if errors.Is(err, pkg.ErrBatchTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			// the client went away, nobody is left to answer
+			log.Debug().Err(err).Int("inputs", len(req.Inputs)).Msg("Cancelled batch")
+			c.Abort()
+			return
+		}
+
+		responses := make([]CompleteResponse, len(suggestions))
+		for i := range suggestions {
+			responses[i] = render.newCompleteResponse(suggestions[i], options.Count)
 		}
 
 		if !req.Debug {
 			for i := range responses {
 				responses[i].Matches = nil
+				responses[i].Adjustments = nil
 			}
 		}
 
 		c.JSON(http.StatusOK, responses)
 	})
 
+	router.GET("/lattice", func(c *gin.Context) {
+		top := 5
+		_, err := fmt.Sscanf(c.DefaultQuery("top", "5"), "%d", &top)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid top"})
+			return
+		}
+
+		suggestions := s.completer.Suggest(c.Query("input"), pkg.SuggestOptions{
+			Count:   top,
+			Context: s.completer.NewContext(c.Query("context"), c.QueryArray("siblings")),
+			Lattice: true,
+		})
+		if suggestions.Lattice == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("input is longer than %d characters", pkg.MaxInputLength)})
+			return
+		}
+		graph := pkg.NewLatticeGraph(suggestions.Lattice, suggestions.HashTags)
+
+		switch c.DefaultQuery("format", "svg") {
+		case "dot":
+			c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.DOT()))
+		case "svg":
+			c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(graph.SVG()))
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format"})
+		}
+	})
+
+	router.GET("/handles", func(c *gin.Context) {
+		name := c.Query("name")
+		response := HandleVariantsResponse{
+			Name:     name,
+			Variants: make([]*HandleVariant, 0),
+		}
+		for _, v := range s.completer.HandleVariants(name) {
+			response.Variants = append(response.Variants, &HandleVariant{
+				Handle:    v.Handle,
+				Words:     v.Words,
+				Ambiguous: v.Ambiguous,
+			})
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+
+	router.GET("/check", func(c *gin.Context) {
+		margin := pkg.DefaultCheckMargin
+		_, err := fmt.Sscanf(c.DefaultQuery("margin", fmt.Sprintf("%f", margin)), "%f", &margin)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid margin"})
+			return
+		}
+
+		c.JSON(http.StatusOK, s.check(c.Query("input"), margin))
+	})
+
+	router.POST("/check", func(c *gin.Context) {
+		req := CheckRequest{Margin: pkg.DefaultCheckMargin}
+		err := c.BindJSON(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		responses := make([]CheckResponse, len(req.Inputs))
+		for i, input := range req.Inputs {
+			responses[i] = s.check(input, req.Margin)
+		}
+
+		c.JSON(http.StatusOK, responses)
+	})
+
+	router.POST("/rewrite", func(c *gin.Context) {
+		var req RewriteRequest
+		err := c.BindJSON(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		format, err := pkg.ParseTextFormat(req.Format)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		text, rewrites := s.completer.Rewrite(req.Text, format)
+		response := RewriteResponse{
+			Text:     text,
+			Format:   string(format),
+			Rewrites: make([]*HashTagRewrite, len(rewrites)),
+		}
+		for i, r := range rewrites {
+			response.Rewrites[i] = &HashTagRewrite{
+				Original:       r.Original,
+				Rewritten:      r.Rewritten,
+				Start:          r.Start,
+				End:            r.End,
+				RewrittenStart: r.RewrittenStart,
+				RewrittenEnd:   r.RewrittenEnd,
+			}
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+
+	router.POST("/select", func(c *gin.Context) {
+		var req SelectRequest
+		err := c.BindJSON(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		words := req.Words
+		var count int
+		if len(words) > 0 {
+			count, err = s.completer.Feedback.Select(req.Input, words)
+		} else {
+			words, count, err = s.completer.Feedback.SelectTag(req.Input, req.Tag)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, SelectResponse{
+			Input: req.Input,
+			Words: words,
+			Count: count,
+		})
+	})
+
+	if s.adminToken != "" {
+		s.addAdminRoutes(router.Group("/admin", s.requireAdmin))
+	} else {
+		log.Warn().Msg("The /admin endpoints are disabled without an admin token")
+	}
+
+	router.POST("/refine", func(c *gin.Context) {
+		req := RefineRequest{Count: 5}
+		err := c.BindJSON(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		response, err := s.refine(req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+
 	fs := EmbedFolder(webFS, "web", true)
 	router.Use(static.Serve("/", fs))
 
-	addr := ":" + s.port
-	log.Info().Str("port", s.port).Msg("Starting server")
-	return router.Run(addr)
+	return router
+}
+
+// addAdminRoutes registers the /admin endpoints in admin.
+func (s *Server) addAdminRoutes(admin *gin.RouterGroup) {
+	admin.POST("/reload", func(c *gin.Context) {
+		previous := s.completer.Dictionary.Version()
+		lexicon, err := s.reloader.Reload()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   err.Error(),
+				"version": previous,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, ReloadResponse{
+			Version:  lexicon.Version,
+			Previous: previous,
+			Words:    lexicon.Words,
+		})
+	})
+
+	admin.GET("/cache", func(c *gin.Context) {
+		if s.completer.Cache == nil {
+			c.JSON(http.StatusOK, CacheResponse{})
+			return
+		}
+		c.JSON(http.StatusOK, NewCacheResponse(s.completer.Cache.Stats()))
+	})
+
+	admin.DELETE("/cache", func(c *gin.Context) {
+		if s.completer.Cache != nil {
+			s.completer.Cache.Purge()
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	admin.GET("/words", func(c *gin.Context) {
+		c.JSON(http.StatusOK, NewDictionaryResponse(s.completer.Dictionary.Entries()))
+	})
+
+	updateWords := func(update func(req DictionaryRequest) error) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			var req DictionaryRequest
+			err := c.BindJSON(&req)
+			if err != nil || len(req.Words) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+				return
+			}
+
+			err = update(req)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(http.StatusOK, NewDictionaryResponse(s.completer.Dictionary.Entries()))
+		}
+	}
+	admin.POST("/words/add", updateWords(func(req DictionaryRequest) error {
+		return s.completer.Dictionary.AddWords(req.Frequency, req.Words...)
+	}))
+	admin.POST("/words/remove", updateWords(func(req DictionaryRequest) error {
+		return s.completer.Dictionary.RemoveWords(req.Words...)
+	}))
+	admin.POST("/words/reweight", updateWords(func(req DictionaryRequest) error {
+		entries := make([]pkg.DictionaryEntry, len(req.Words))
+		for i, w := range req.Words {
+			entries[i] = pkg.DictionaryEntry{Word: w, Status: pkg.WordReweighted, Frequency: req.Frequency}
+		}
+		return s.completer.Dictionary.Apply(entries...)
+	}))
+
+	admin.GET("/overrides", func(c *gin.Context) {
+		c.JSON(http.StatusOK, OverridesResponse{Overrides: s.overrides.List()})
+	})
+
+	updateOverrides := func(update func(req OverrideRequest) error) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			var req OverrideRequest
+			err := c.BindJSON(&req)
+			if err != nil || req.Input == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+				return
+			}
+
+			err = update(req)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(http.StatusOK, OverridesResponse{Overrides: s.overrides.List()})
+		}
+	}
+	admin.POST("/overrides/set", updateOverrides(func(req OverrideRequest) error {
+		words := req.Words
+		if len(words) == 0 {
+			words = pkg.SplitCamelCase(strings.TrimPrefix(req.Tag, "#"))
+		}
+		return s.overrides.Set(req.Input, words)
+	}))
+	admin.POST("/overrides/remove", updateOverrides(func(req OverrideRequest) error {
+		return s.overrides.Delete(req.Input)
+	}))
+
+	userWords := func(c *gin.Context) {
+		user := c.Param("user")
+		words, err := s.completer.Users.Words(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, UserWordsResponse{User: user, Words: words})
+	}
+	updateUserWords := func(update func(user string, words ...string) error) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			var req UserWordsRequest
+			err := c.BindJSON(&req)
+			if err != nil || len(req.Words) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+				return
+			}
+
+			err = update(c.Param("user"), req.Words...)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			userWords(c)
+		}
+	}
+	admin.GET("/users/:user/words", userWords)
+	admin.POST("/users/:user/words/add", updateUserWords(s.completer.Users.Add))
+	admin.POST("/users/:user/words/remove", updateUserWords(s.completer.Users.Remove))
+}
+
+// requireAdmin rejects the requests without the admin token, and all of them if the
+// server has none.
+func (s *Server) requireAdmin(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if s.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+	}
+}
+
+// loadLexicon loads the lexicon snapshot given with --lexicon, or builds the dictionary
+// trie and loads the word frequencies from the files given on the command line.
+func loadLexicon(cmd *cobra.Command) *pkg.Lexicon {
+	start := time.Now()
+	snapshotPath, err := cmd.Flags().GetString("lexicon")
+	cobra.CheckErr(err)
+
+	var lexicon *pkg.Lexicon
+	if snapshotPath != "" {
+		verify, err := cmd.Flags().GetBool("verify-lexicon")
+		cobra.CheckErr(err)
+		lexicon, err = pkg.OpenLexiconSnapshot(snapshotPath, verify)
+		cobra.CheckErr(err)
+	} else {
+		dicts, err := cmd.Flags().GetStringSlice("dict")
+		cobra.CheckErr(err)
+		frequencyPath, err := cmd.Flags().GetString("frequency")
+		cobra.CheckErr(err)
+		lexicon, err = pkg.LoadLexicon(dicts, frequencyPath, getMatcherKind(cmd))
+		cobra.CheckErr(err)
+	}
+
+	log.Info().
+		Str("version", lexicon.Version).
+		Int("words", lexicon.Words).
+		Str("snapshot", snapshotPath).
+		Dur("duration", time.Since(start)).
+		Msg("Loaded the lexicon")
+	return lexicon
+}
+
+// loadCompleter loads the lexicon and the learned data given on the command line.
+// The returned reloader reloads the lexicon from the same files.
+func loadCompleter(cmd *cobra.Command) (*pkg.Completer, *pkg.LexiconReloader) {
+	lexicon := loadLexicon(cmd)
+
+	storeSpec, err := cmd.Flags().GetString("store")
+	cobra.CheckErr(err)
+	store, err := pkg.OpenStore(storeSpec)
+	cobra.CheckErr(err)
+
+	dictionary, err := pkg.LoadDictionary(lexicon, store)
+	cobra.CheckErr(err)
+
+	completer := pkg.NewDictionaryCompleter(dictionary)
+	completer.Rerankers = loadRerankers(cmd, store)
+	completer.Users = pkg.NewUserDictionaries(store)
+	completer.Profile = loadScoringProfile(cmd)
+	completer.Feedback, err = pkg.LoadFeedback(store)
+	cobra.CheckErr(err)
+	cacheSize, err := cmd.Flags().GetInt("cache-size")
+	cobra.CheckErr(err)
+	if cacheSize > 0 {
+		completer.Cache = pkg.NewSuggestionCache(cacheSize)
+	}
+	snapshotPath, err := cmd.Flags().GetString("lexicon")
+	cobra.CheckErr(err)
+	if snapshotPath != "" {
+		return completer, pkg.NewSnapshotReloader(dictionary, snapshotPath)
+	}
+	dicts, err := cmd.Flags().GetStringSlice("dict")
+	cobra.CheckErr(err)
+	frequencyPath, err := cmd.Flags().GetString("frequency")
+	cobra.CheckErr(err)
+	reloader := pkg.NewLexiconReloader(dictionary, dicts, frequencyPath)
+	reloader.Matcher = getMatcherKind(cmd)
+	return completer, reloader
+}
+
+func getMatcherKind(cmd *cobra.Command) pkg.MatcherKind {
+	s, err := cmd.Flags().GetString("matcher")
+	cobra.CheckErr(err)
+	kind, err := pkg.ParseMatcherKind(s)
+	cobra.CheckErr(err)
+	return kind
+}
+
+// startReloading reloads the lexicon on SIGHUP, and when its files change if --watch is set.
+func startReloading(cmd *cobra.Command, reloader *pkg.LexiconReloader) {
+	watch, err := cmd.Flags().GetDuration("watch")
+	cobra.CheckErr(err)
+	if watch > 0 {
+		go reloader.Watch(watch, nil)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			// errors are logged by the reloader, which keeps the current lexicon
+			_, _ = reloader.Reload()
+		}
+	}()
+}
+
+// loadScoringProfile loads the ranking strategies of the deployment, the built-in
+// ones if no profile is given.
+func loadScoringProfile(cmd *cobra.Command) *pkg.ScoringProfile {
+	profilePath, err := cmd.Flags().GetString("profile")
+	cobra.CheckErr(err)
+	if profilePath == "" {
+		return pkg.NewScoringProfile()
+	}
+
+	profile, err := pkg.LoadScoringProfile(profilePath)
+	cobra.CheckErr(err)
+	return profile
+}
+
+// overrideReranker returns the override reranker of completer, adding one if it has none.
+func overrideReranker(completer *pkg.Completer) *pkg.OverrideReranker {
+	for _, r := range completer.Rerankers {
+		if overrides, ok := r.(*pkg.OverrideReranker); ok {
+			return overrides
+		}
+	}
+	overrides := pkg.NewOverrideReranker(nil)
+	completer.Rerankers = append(completer.Rerankers, overrides)
+	return overrides
+}
+
+// loadRerankers builds the re-ranking chain of the deployment from the command line:
+// the blocklist demotes words first, then the diversity re-ranker spreads the
+// suggestions, and the curated overrides come last so that they always win.
+func loadRerankers(cmd *cobra.Command, store pkg.Store) pkg.RerankChain {
+	chain := pkg.RerankChain{}
+
+	blocklistPath, err := cmd.Flags().GetString("blocklist")
+	cobra.CheckErr(err)
+	if blocklistPath != "" {
+		words, err := pkg.LoadBlocklist(blocklistPath)
+		cobra.CheckErr(err)
+		chain = append(chain, pkg.NewBlocklistReranker(words, pkg.DefaultBlocklistFactor))
+	}
+
+	diversity, err := cmd.Flags().GetFloat64("diversity")
+	cobra.CheckErr(err)
+	if diversity > 0 && diversity < 1 {
+		chain = append(chain, pkg.NewDiversityReranker(diversity))
+	}
+
+	// the overrides of the file take precedence over the stored ones, the reranker is
+	// there even without overrides so that they can be added at runtime
+	var fileOverrides map[string][]string
+	overridesPath, err := cmd.Flags().GetString("overrides")
+	cobra.CheckErr(err)
+	if overridesPath != "" {
+		fileOverrides, err = pkg.LoadOverrides(overridesPath)
+		cobra.CheckErr(err)
+	}
+	overrides, err := pkg.LoadOverrideReranker(store, fileOverrides)
+	cobra.CheckErr(err)
+	chain = append(chain, overrides)
+
+	return chain
+}
+
+func addRerankFlags(cmd *cobra.Command) {
+	cmd.Flags().String("blocklist", "", "File of words to demote, one per line")
+	cmd.Flags().Float64("diversity", 0, fmt.Sprintf("Factor demoting suggestions that start like better ones, such as %.1f (0 disables it)", pkg.DefaultDiversityFactor))
+	cmd.Flags().String("overrides", "", "File of curated CamelCase segmentations to put first, one per line")
+}
+
+func addProfileFlag(cmd *cobra.Command) {
+	cmd.Flags().String("profile", "", "JSON scoring profile of the ranking strategies and their default")
+}
+
+func addReloadFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("watch", 0, "Interval at which the lexicon files are checked for changes, 0 to only reload on SIGHUP")
+}
+
+func addLexiconFlags(cmd *cobra.Command) {
+	cmd.Flags().String("lexicon", "", "Lexicon snapshot built by the compile command, used instead of --dict and --frequency")
+	cmd.Flags().Bool("verify-lexicon", false, "Verify the checksum of the lexicon snapshot on startup, which reads the whole file")
+	addMatcherFlag(cmd)
+}
+
+func addMatcherFlag(cmd *cobra.Command) {
+	cmd.Flags().String("matcher", string(pkg.AhoCorasickMatcherKind),
+		"Data structure matching the dictionary words: aho-corasick (GBs of memory) or double-array (a few MBs)")
+}
+
+func addCacheFlag(cmd *cobra.Command) {
+	cmd.Flags().Int("cache-size", 10000, "Number of suggestions kept in the result cache, 0 disables it")
+}
+
+func addStoreFlag(cmd *cobra.Command) {
+	cmd.Flags().String("store", "memory", "Where learned data is kept: memory, or bolt:<path> for a database file")
 }
 
 var ServeCmd = &cobra.Command{
@@ -204,22 +1321,31 @@ var ServeCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		port, _ := cmd.Flags().GetString("port")
 
-		dicts, err := cmd.Flags().GetStringSlice("dict")
+		sessionTTL, err := cmd.Flags().GetDuration("session-ttl")
 		cobra.CheckErr(err)
 
-		frequencyPath, err := cmd.Flags().GetString("frequency")
+		adminToken, err := cmd.Flags().GetString("admin-token")
 		cobra.CheckErr(err)
 
-		trie, err := pkg.BuildTrieFromFiles(dicts)
+		batchLimits := pkg.BatchLimits{}
+		batchLimits.Workers, err = cmd.Flags().GetInt("batch-workers")
 		cobra.CheckErr(err)
-
-		frequency, err := pkg.LoadWordFrequencies(frequencyPath)
+		batchLimits.MaxInputs, err = cmd.Flags().GetInt("batch-max-inputs")
+		cobra.CheckErr(err)
+		batchLimits.MaxBytes, err = cmd.Flags().GetInt("batch-max-bytes")
 		cobra.CheckErr(err)
 
+		completer, reloader := loadCompleter(cmd)
+		startReloading(cmd, reloader)
+
 		s := &Server{
-			trie:      trie,
-			frequency: frequency,
-			port:      port,
+			completer:   completer,
+			reloader:    reloader,
+			sessions:    pkg.NewRefineSessions(sessionTTL),
+			port:        port,
+			adminToken:  adminToken,
+			batchLimits: batchLimits,
+			overrides:   overrideReranker(completer),
 		}
 
 		err = s.Run()
@@ -229,14 +1355,49 @@ var ServeCmd = &cobra.Command{
 
 var GrpcCmd = &cobra.Command{
 	Use:   "grpc",
-	Short: "Starts the hashtag server",
+	Short: "Starts the hashtag gRPC server",
 	Run: func(cmd *cobra.Command, args []string) {
+		port, _ := cmd.Flags().GetString("port")
+
+		sessionTTL, err := cmd.Flags().GetDuration("session-ttl")
+		cobra.CheckErr(err)
+
+		completer, reloader := loadCompleter(cmd)
+		startReloading(cmd, reloader)
 
-		//s := grpc.NewServer()
-		//grpc2.RegisterCompleteServer(s, &server{})
+		lis, err := net.Listen("tcp", ":"+port)
+		cobra.CheckErr(err)
+
+		s := grpc.NewServer()
+		api.RegisterCompleteServer(s, grpc2.NewServer(completer, pkg.NewRefineSessions(sessionTTL)))
+
+		log.Info().Str("port", port).Msg("Starting gRPC server")
+		err = s.Serve(lis)
+		cobra.CheckErr(err)
 	},
 }
 
 func init() {
 	ServeCmd.Flags().StringP("port", "p", "8080", "Port to listen on")
+	ServeCmd.Flags().Duration("session-ttl", 5*time.Minute, "Lifetime of idle refinement sessions")
+	ServeCmd.Flags().String("admin-token", "", "Bearer token required by the /admin endpoints, which are disabled if empty")
+	ServeCmd.Flags().Int("batch-workers", 0, "Number of inputs of a POST /complete segmented concurrently, the number of CPUs if 0")
+	ServeCmd.Flags().Int("batch-max-inputs", 1000, "Maximum number of inputs of a POST /complete, 0 for no limit")
+	ServeCmd.Flags().Int("batch-max-bytes", 64*1024, "Maximum total length of the inputs of a POST /complete, 0 for no limit")
+
+	GrpcCmd.Flags().StringP("port", "p", "8081", "Port to listen on")
+	GrpcCmd.Flags().Duration("session-ttl", 5*time.Minute, "Lifetime of idle refinement sessions")
+
+	addRerankFlags(ServeCmd)
+	addRerankFlags(GrpcCmd)
+	addProfileFlag(ServeCmd)
+	addProfileFlag(GrpcCmd)
+	addStoreFlag(ServeCmd)
+	addStoreFlag(GrpcCmd)
+	addReloadFlags(ServeCmd)
+	addReloadFlags(GrpcCmd)
+	addLexiconFlags(ServeCmd)
+	addLexiconFlags(GrpcCmd)
+	addCacheFlag(ServeCmd)
+	addCacheFlag(GrpcCmd)
 }
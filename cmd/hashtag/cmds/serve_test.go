@@ -0,0 +1,44 @@
+package cmds
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wesen/majuscule/pkg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	matcher, err := pkg.BuildMatcher(pkg.DoubleArrayMatcherKind, []string{"pen", "island"})
+	require.NoError(t, err)
+	completer := pkg.NewDictionaryCompleter(pkg.NewLexiconDictionary(&pkg.Lexicon{Matcher: matcher}))
+	completer.Users = pkg.NewUserDictionaries(pkg.NewMemoryStore())
+
+	request := func(s *Server, method string, path string, body string, token string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		s.router().ServeHTTP(w, req)
+		return w.Code
+	}
+	addWords := `{"words": ["penisland"]}`
+
+	// without a token, the admin endpoints don't exist
+	open := &Server{completer: completer, overrides: overrideReranker(completer)}
+	assert.Equal(t, http.StatusNotFound, request(open, http.MethodPost, "/admin/words/add", addWords, ""))
+	assert.Equal(t, http.StatusNotFound, request(open, http.MethodDelete, "/admin/cache", "", ""))
+	assert.Empty(t, completer.Dictionary.Entries())
+
+	s := &Server{completer: completer, adminToken: "secret", overrides: overrideReranker(completer)}
+	assert.Equal(t, http.StatusUnauthorized, request(s, http.MethodPost, "/admin/words/add", addWords, ""))
+	assert.Equal(t, http.StatusUnauthorized, request(s, http.MethodPost, "/admin/words/add", addWords, "guess"))
+	assert.Empty(t, completer.Dictionary.Entries())
+	assert.Equal(t, http.StatusOK, request(s, http.MethodPost, "/admin/words/add", addWords, "secret"))
+	assert.Len(t, completer.Dictionary.Entries(), 1)
+}
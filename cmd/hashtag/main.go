@@ -13,7 +13,13 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(cmds.ReplCmd)
 	rootCmd.AddCommand(cmds.CompleteCmd)
+	rootCmd.AddCommand(cmds.CheckCmd)
+	rootCmd.AddCommand(cmds.RewriteCmd)
+	rootCmd.AddCommand(cmds.LatticeCmd)
+	rootCmd.AddCommand(cmds.WordsCmd)
 	rootCmd.AddCommand(cmds.ServeCmd)
+	rootCmd.AddCommand(cmds.CompileCmd)
+	rootCmd.AddCommand(cmds.GrpcCmd)
 
 	wordLists := []string{
 		"test_data/words",
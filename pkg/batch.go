@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBatchTooLarge is returned for batches over their BatchLimits.
+var ErrBatchTooLarge = errors.New("batch too large")
+
+// BatchLimits bound the work of a batch of inputs.
+type BatchLimits struct {
+	// Workers is the number of inputs segmented concurrently, GOMAXPROCS if 0
+	Workers int
+	// MaxInputs is the maximum number of inputs of a batch, 0 meaning no limit
+	MaxInputs int
+	// MaxBytes is the maximum total length of the inputs of a batch, 0 meaning no limit
+	MaxBytes int
+}
+
+// Check returns an ErrBatchTooLarge error if inputs are over the limits.
+func (l BatchLimits) Check(inputs []string) error {
+	if l.MaxInputs > 0 && len(inputs) > l.MaxInputs {
+		return fmt.Errorf("%w: %d inputs, the limit is %d", ErrBatchTooLarge, len(inputs), l.MaxInputs)
+	}
+	if l.MaxBytes > 0 {
+		total := 0
+		for _, input := range inputs {
+			total += len(input)
+		}
+		if total > l.MaxBytes {
+			return fmt.Errorf("%w: %d bytes of inputs, the limit is %d", ErrBatchTooLarge, total, l.MaxBytes)
+		}
+	}
+	return nil
+}
+
+// SuggestBatch computes the suggestions of each of inputs with Suggest, in the order of
+// inputs. The inputs are spread over limits.Workers goroutines, which stop picking inputs
+// once ctx is done, the error of ctx being returned if some inputs were left.
+func (c *Completer) SuggestBatch(ctx context.Context, inputs []string, options SuggestOptions, limits BatchLimits) ([]*Suggestions, error) {
+	err := limits.Check(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := limits.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	ret := make([]*Suggestions, len(inputs))
+	// next is the index of the next input to segment, past the end once all were picked
+	var next atomic.Int64
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1)) - 1
+				if i >= len(inputs) {
+					return
+				}
+				ret[i] = c.Suggest(inputs[i], options)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if int(next.Load()) < len(inputs) {
+		return nil, ctx.Err()
+	}
+	return ret, nil
+}
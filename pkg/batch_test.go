@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSuggestBatch(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"pen", "island", "penis", "is", "land", "fedi", "verse"}), nil)
+	inputs := []string{}
+	for i := 0; i < 50; i++ {
+		inputs = append(inputs, "penisland", "fediverse", "", fmt.Sprintf("pen%d", i))
+	}
+
+	for _, workers := range []int{0, 1, 3, 500} {
+		suggestions, err := completer.SuggestBatch(context.Background(), inputs, SuggestOptions{Count: 2}, BatchLimits{Workers: workers})
+		require.NoError(t, err)
+		require.Len(t, suggestions, len(inputs))
+		for i, s := range suggestions {
+			assert.Equal(t, inputs[i], s.Input)
+			assert.Equal(t, suggestionTags(completer.Suggest(inputs[i], SuggestOptions{Count: 2})), suggestionTags(s))
+		}
+	}
+
+	suggestions, err := completer.SuggestBatch(context.Background(), nil, SuggestOptions{}, BatchLimits{})
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
+
+func TestSuggestBatchLimits(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"pen", "island"}), nil)
+	inputs := []string{"penisland", "islandpen", "pen"}
+
+	_, err := completer.SuggestBatch(context.Background(), inputs, SuggestOptions{}, BatchLimits{MaxInputs: 2})
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+	_, err = completer.SuggestBatch(context.Background(), inputs, SuggestOptions{}, BatchLimits{MaxBytes: 20})
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+
+	suggestions, err := completer.SuggestBatch(context.Background(), inputs, SuggestOptions{}, BatchLimits{MaxInputs: 3, MaxBytes: 21})
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 3)
+}
+
+func TestSuggestBatchCancel(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"pen", "island"}), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := completer.SuggestBatch(ctx, []string{"penisland", "islandpen"}, SuggestOptions{}, BatchLimits{Workers: 2})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// SuggestionCache keeps the suggestions of the most recently requested inputs, so that
+// the requests fired on every keystroke and the popular hashtags many users type are only
+// computed once. Concurrent requests for the same input and options share one computation.
+//
+// The entries are only valid for the lexicon, the runtime dictionary changes, the
+// re-rankers and the user dictionaries they were computed with, the cache is emptied
+// whenever one of them changes. A selection only replaces the entries of its own input,
+// so that selecting suggestions doesn't empty the cache: the entries of the other inputs
+// keep the word boosts they were computed with until they are evicted.
+// The returned suggestions are shared and must not be modified.
+type SuggestionCache struct {
+	// Capacity is the maximum number of entries
+	Capacity int
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	// generation identifies the state of the completer the entries were computed with
+	generation string
+	group      singleflight.Group
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	// shared counts the misses that waited for the computation of an identical request
+	shared    atomic.Int64
+	evictions atomic.Int64
+	purges    atomic.Int64
+}
+
+type cacheEntry struct {
+	key         string
+	suggestions *Suggestions
+}
+
+// CacheStats are the counters of a SuggestionCache.
+type CacheStats struct {
+	Entries   int
+	Capacity  int
+	Hits      int64
+	Misses    int64
+	Shared    int64
+	Evictions int64
+	Purges    int64
+}
+
+func NewSuggestionCache(capacity int) *SuggestionCache {
+	return &SuggestionCache{
+		Capacity: capacity,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the suggestions cached under key for the given generation, or computes
+// them with compute. The cache is emptied if the generation changed.
+func (sc *SuggestionCache) Get(generation string, key string, compute func() *Suggestions) *Suggestions {
+	key = generation + "\x00" + key
+
+	sc.mu.Lock()
+	if sc.generation != generation {
+		sc.purge(generation)
+	}
+	if e, ok := sc.items[key]; ok {
+		sc.lru.MoveToFront(e)
+		sc.mu.Unlock()
+		sc.hits.Add(1)
+		return e.Value.(*cacheEntry).suggestions
+	}
+	sc.mu.Unlock()
+	sc.misses.Add(1)
+
+	v, _, shared := sc.group.Do(key, func() (interface{}, error) {
+		suggestions := compute()
+		sc.add(generation, key, suggestions)
+		return suggestions, nil
+	})
+	if shared {
+		sc.shared.Add(1)
+	}
+	return v.(*Suggestions)
+}
+
+func (sc *SuggestionCache) add(generation string, key string, suggestions *Suggestions) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	// suggestions computed while the completer changed are not kept
+	if sc.generation != generation || sc.Capacity <= 0 {
+		return
+	}
+	if e, ok := sc.items[key]; ok {
+		e.Value.(*cacheEntry).suggestions = suggestions
+		sc.lru.MoveToFront(e)
+		return
+	}
+	sc.items[key] = sc.lru.PushFront(&cacheEntry{key: key, suggestions: suggestions})
+	for sc.lru.Len() > sc.Capacity {
+		e := sc.lru.Back()
+		sc.lru.Remove(e)
+		delete(sc.items, e.Value.(*cacheEntry).key)
+		sc.evictions.Add(1)
+	}
+}
+
+// purge empties the cache, the lock has to be held.
+func (sc *SuggestionCache) purge(generation string) {
+	if sc.lru.Len() > 0 {
+		sc.purges.Add(1)
+	}
+	sc.lru.Init()
+	sc.items = make(map[string]*list.Element)
+	sc.generation = generation
+}
+
+// Purge empties the cache.
+func (sc *SuggestionCache) Purge() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.purge(sc.generation)
+}
+
+func (sc *SuggestionCache) Stats() CacheStats {
+	sc.mu.Lock()
+	entries := sc.lru.Len()
+	sc.mu.Unlock()
+
+	return CacheStats{
+		Entries:   entries,
+		Capacity:  sc.Capacity,
+		Hits:      sc.hits.Load(),
+		Misses:    sc.misses.Load(),
+		Shared:    sc.shared.Load(),
+		Evictions: sc.evictions.Load(),
+		Purges:    sc.purges.Load(),
+	}
+}
+
+// cacheGeneration identifies the lexicon, the runtime dictionary changes, the
+// re-rankers and the dictionaries of the users the suggestions of c depend on. The
+// selections are part of the key of each input instead, see cacheKey.
+func (c *Completer) cacheGeneration() string {
+	return fmt.Sprintf("%s/%d/%d/%d", c.Dictionary.Version(), c.Dictionary.Revision(),
+		c.Rerankers.Revision(), c.Users.Revision())
+}
+
+// cacheKey is the key of CacheKey along with the selections of input.
+func (c *Completer) cacheKey(input string, options SuggestOptions) string {
+	return fmt.Sprintf("%s\x00%d", options.CacheKey(input), c.Feedback.InputRevision(input))
+}
+
+// CacheKey is the normalized form of an input and its options: options that give the
+// same suggestions, such as an empty mode and the hashtag mode, or the same constraints
+// listed in another order, have the same key. The input itself is kept as is, since the
+// segmentation depends on its case.
+func (o SuggestOptions) CacheKey(input string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\x00%s\x00%d\x00%t\x00%s\x00", input, normalizeMode(o.Mode), o.Count, o.Lattice, o.User)
+	if o.Strategy != nil {
+		fmt.Fprintf(&sb, "%+v", *o.Strategy)
+	}
+	sb.WriteByte(0)
+	if o.Context != nil {
+		writeSortedKeys(&sb, o.Context.Words)
+		sb.WriteByte('|')
+		writeSortedKeys(&sb, o.Context.Bigrams)
+	}
+	sb.WriteByte(0)
+	if !o.Constraints.IsEmpty() {
+		fmt.Fprintf(&sb, "%d %d %v %v", o.Constraints.MinWordLength, o.Constraints.MaxWords,
+			sortedWords(o.Constraints.ShortWords), sortedWords(o.Constraints.Keep))
+	}
+	return sb.String()
+}
+
+func writeSortedKeys(sb *strings.Builder, m map[string]bool) {
+	keys := make([]string, 0, len(m))
+	for k, v := range m {
+		if v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	sb.WriteString(strings.Join(keys, ","))
+}
+
+func sortedWords(words []string) []string {
+	ret := make([]string, len(words))
+	for i, w := range words {
+		ret[i] = strings.ToLower(w)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSuggestionCache(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"pen", "island", "penis", "is", "land", "fedi", "verse"}), nil)
+	completer.Cache = NewSuggestionCache(2)
+
+	first := completer.Suggest("penisland", SuggestOptions{})
+	assert.Same(t, first, completer.Suggest("penisland", SuggestOptions{Mode: HashTagMode}))
+	assert.NotSame(t, first, completer.Suggest("penisland", SuggestOptions{Count: 1}))
+	assert.Equal(t, CacheStats{Entries: 2, Capacity: 2, Hits: 1, Misses: 2}, completer.Cache.Stats())
+
+	// the least recently used entry is evicted
+	assert.Same(t, first, completer.Suggest("penisland", SuggestOptions{}))
+	completer.Suggest("fediverse", SuggestOptions{})
+	assert.Same(t, first, completer.Suggest("penisland", SuggestOptions{}))
+	stats := completer.Cache.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(3), stats.Hits)
+
+	completer.Cache.Purge()
+	assert.NotSame(t, first, completer.Suggest("penisland", SuggestOptions{}))
+	assert.Equal(t, int64(1), completer.Cache.Stats().Purges)
+}
+
+func TestSuggestionCacheModes(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"jane", "doe", "experts", "exchange"}), nil)
+	completer.Cache = NewSuggestionCache(10)
+
+	// the modes spelled differently share the suggestions of the mode they stand for
+	handle := completer.Suggest("@janedoe_1990", SuggestOptions{Mode: "Handle"})
+	require.NotEmpty(t, handle.HashTags)
+	assert.Equal(t, "JaneDoe_1990", handle.HashTags[0].Tag())
+	assert.Same(t, handle, completer.Suggest("@janedoe_1990", SuggestOptions{Mode: HandleMode}))
+	domain := completer.Suggest("expertsexchange.com", SuggestOptions{Mode: "url"})
+	require.NotEmpty(t, domain.HashTags)
+	assert.Equal(t, "ExpertsExchange.com", domain.HashTags[0].Tag())
+	assert.Same(t, domain, completer.Suggest("expertsexchange.com", SuggestOptions{Mode: DomainMode}))
+}
+
+func TestSuggestionCacheInvalidation(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"fedi", "verse", "who", "whore", "represents", "presents"}), map[string]int{"whore": 100000})
+	completer.Cache = NewSuggestionCache(10)
+
+	assert.Equal(t, "FediVerse", completer.Suggest("fediverse", SuggestOptions{}).HashTags[0].Tag())
+	require.NoError(t, completer.Dictionary.AddWords(100, "fediverse"))
+	assert.Equal(t, "Fediverse", completer.Suggest("fediverse", SuggestOptions{}).HashTags[0].Tag())
+
+	assert.Equal(t, "WhorePresents", completer.Suggest("whorepresents", SuggestOptions{}).HashTags[0].Tag())
+	_, err := completer.Feedback.Select("whorepresents", []string{"Who", "Represents"})
+	require.NoError(t, err)
+	assert.Equal(t, "WhoRepresents", completer.Suggest("whorepresents", SuggestOptions{}).HashTags[0].Tag())
+
+	// the words added at runtime are kept across reloads
+	completer.Dictionary.Reload(&Lexicon{Matcher: BuildDoubleArrayTrie([]string{"fed", "iverse"}), Version: "v2"})
+	tags := []string{}
+	for _, h := range completer.Suggest("fediverse", SuggestOptions{}).HashTags {
+		tags = append(tags, h.Tag())
+	}
+	assert.ElementsMatch(t, []string{"Fediverse", "FedIverse"}, tags)
+
+	stats := completer.Cache.Stats()
+	assert.Equal(t, int64(0), stats.Hits)
+	assert.Equal(t, int64(2), stats.Purges)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func TestSuggestionCacheSelections(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"fedi", "verse", "who", "whore", "represents", "presents"}), map[string]int{"whore": 100000})
+	completer.Cache = NewSuggestionCache(10)
+
+	fediverse := completer.Suggest("fediverse", SuggestOptions{})
+	assert.Equal(t, "WhorePresents", completer.Suggest("whorepresents", SuggestOptions{}).HashTags[0].Tag())
+	_, err := completer.Feedback.Select("WhoRepresents", []string{"Who", "Represents"})
+	require.NoError(t, err)
+
+	// a selection only replaces the suggestions of its input
+	assert.Equal(t, "WhoRepresents", completer.Suggest("whorepresents", SuggestOptions{}).HashTags[0].Tag())
+	assert.Same(t, fediverse, completer.Suggest("fediverse", SuggestOptions{}))
+	stats := completer.Cache.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(0), stats.Purges)
+}
+
+func TestSuggestionCacheSharesComputations(t *testing.T) {
+	cache := NewSuggestionCache(10)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	computed := 0
+	compute := func() *Suggestions {
+		computed++
+		close(started)
+		<-release
+		return &Suggestions{Input: "penisland"}
+	}
+
+	results := make([]*Suggestions, 5)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0] = cache.Get("v1", "penisland", compute)
+	}()
+	<-started
+	for i := 1; i < len(results); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = cache.Get("v1", "penisland", compute)
+		}(i)
+	}
+	// wait for the other requests to be waiting on the computation
+	for cache.Stats().Misses < int64(len(results)) {
+		runtime.Gosched()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, computed)
+	for _, r := range results {
+		assert.Same(t, results[0], r)
+	}
+	assert.Equal(t, 1, cache.Stats().Entries)
+}
+
+func TestSuggestOptionsCacheKey(t *testing.T) {
+	assert.Equal(t, SuggestOptions{}.CacheKey("PenIsland"), SuggestOptions{Mode: "Hashtag"}.CacheKey("PenIsland"))
+	assert.NotEqual(t, SuggestOptions{}.CacheKey("PenIsland"), SuggestOptions{}.CacheKey("penisland"))
+	assert.NotEqual(t, SuggestOptions{}.CacheKey("penisland"), SuggestOptions{Mode: HandleMode}.CacheKey("penisland"))
+	assert.NotEqual(t, SuggestOptions{}.CacheKey("penisland"), SuggestOptions{Lattice: true}.CacheKey("penisland"))
+
+	assert.Equal(t,
+		SuggestOptions{Constraints: &Constraints{Keep: []string{"Pen", "island"}}}.CacheKey("penisland"),
+		SuggestOptions{Constraints: &Constraints{Keep: []string{"island", "pen"}}}.CacheKey("penisland"))
+	assert.Equal(t,
+		SuggestOptions{}.CacheKey("penisland"),
+		SuggestOptions{Constraints: &Constraints{ShortWords: []string{"a"}}}.CacheKey("penisland"))
+	assert.NotEqual(t,
+		SuggestOptions{}.CacheKey("penisland"),
+		SuggestOptions{Constraints: &Constraints{MaxWords: 2}}.CacheKey("penisland"))
+
+	assert.Equal(t,
+		SuggestOptions{Context: &Context{Words: map[string]bool{"pen": true, "ink": true}}}.CacheKey("penisland"),
+		SuggestOptions{Context: &Context{Words: map[string]bool{"ink": true, "pen": true, "paper": false}}}.CacheKey("penisland"))
+	assert.NotEqual(t,
+		SuggestOptions{}.CacheKey("penisland"),
+		SuggestOptions{Strategy: &Strategy{Name: "max"}}.CacheKey("penisland"))
+}
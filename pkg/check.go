@@ -0,0 +1,191 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultCheckMargin is how much better (as a ratio of Score()) the best segmentation
+// has to be than the one given by the user before we suggest a fix.
+const DefaultCheckMargin = 1.25
+
+type DubiousBoundaryKind string
+
+const (
+	// UnknownWord means the word starting at the boundary is not in the dictionary
+	UnknownWord DubiousBoundaryKind = "unknown-word"
+	// ExtraBoundary means the best segmentation doesn't split the hashtag at this position
+	ExtraBoundary DubiousBoundaryKind = "extra-boundary"
+	// MissingBoundary means the best segmentation splits the hashtag at this position,
+	// but the user didn't
+	MissingBoundary DubiousBoundaryKind = "missing-boundary"
+)
+
+type DubiousBoundary struct {
+	Pos  int
+	Kind DubiousBoundaryKind
+	Word string
+}
+
+func (b *DubiousBoundary) String() string {
+	return fmt.Sprintf("%s at %d (%s)", b.Kind, b.Pos, b.Word)
+}
+
+// CheckResult is the verdict on a hashtag that was already capitalized by its author.
+type CheckResult struct {
+	// HashTag is the segmentation given by the author, scored with the lattice
+	HashTag *HashTag
+	// Rank is the 1-based rank of HashTag among the segmentations explored by the search
+	Rank int
+	// Total is the number of segmentations explored by the search
+	Total   int
+	Dubious []*DubiousBoundary
+	// Suggestion is the best segmentation, if it is clearly better than HashTag
+	Suggestion *HashTag
+}
+
+// SplitCamelCase splits a capitalized hashtag into its words. A new word starts
+// at each uppercase letter, except inside a run of uppercase letters (an acronym), which
+// only ends before the last uppercase letter followed by a lowercase one, as in "NASAMission".
+// Runs of digits are considered words of their own.
+func SplitCamelCase(tag string) []string {
+	runes := []rune(tag)
+	words := make([]string, 0)
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		split := false
+		switch {
+		case unicode.IsDigit(cur) != unicode.IsDigit(prev):
+			split = true
+		case unicode.IsUpper(cur) && !unicode.IsUpper(prev):
+			split = true
+		case unicode.IsUpper(cur) && unicode.IsUpper(prev) &&
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			split = true
+		}
+		if split {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+func boundaries(words []string) map[int]bool {
+	ret := make(map[int]bool, len(words))
+	pos := 0
+	for _, w := range words[:len(words)-1] {
+		pos += len(w)
+		ret[pos] = true
+	}
+	return ret
+}
+
+// CheckSegmentation scores the segmentation of sm.String into words, ranks it against
+// the segmentations found by ComputeHashTagsIterative, and flags the boundaries that
+// disagree with the best one. The words have to concatenate to sm.String, ignoring case,
+// and have its length in bytes, since their positions are the ones of its lattice.
+func (sm *StringMatches) CheckSegmentation(words []string, margin float64) (*CheckResult, error) {
+	joined := strings.Join(words, "")
+	if !strings.EqualFold(joined, sm.String) || len(joined) != len(sm.String) || len(words) == 0 {
+		return nil, fmt.Errorf("words %v do not cover %s", words, sm.String)
+	}
+
+	ret := &CheckResult{
+		Dubious: make([]*DubiousBoundary, 0),
+	}
+
+	scores := make([]float64, len(words))
+	capitalized := make([]string, len(words))
+	pos := 0
+	for i, w := range words {
+		lower := strings.ToLower(w)
+		capitalized[i] = capitalize(lower)
+		found := false
+		for _, m := range sm.AllMatches[pos] {
+			if m.Match == lower {
+				scores[i] = m.Score
+				found = true
+				break
+			}
+		}
+		if !found {
+			ret.Dubious = append(ret.Dubious, &DubiousBoundary{Pos: pos, Kind: UnknownWord, Word: w})
+		}
+		pos += len(w)
+	}
+	ret.HashTag = NewHashTag(capitalized, scores)
+
+	hashTags := sm.ComputeHashTagsIterative(0)
+	ret.Total = len(hashTags)
+	ret.Rank = 1
+	for _, h := range hashTags {
+		if h.Score() > ret.HashTag.Score() {
+			ret.Rank++
+		}
+	}
+
+	if len(hashTags) == 0 {
+		return ret, nil
+	}
+
+	best := hashTags[0]
+	bestBoundaries := boundaries(best.Words)
+	userBoundaries := boundaries(words)
+	pos = 0
+	for i, w := range best.Words[:len(best.Words)-1] {
+		pos += len(w)
+		if !userBoundaries[pos] {
+			ret.Dubious = append(ret.Dubious, &DubiousBoundary{Pos: pos, Kind: MissingBoundary, Word: best.Words[i+1]})
+		}
+	}
+	pos = 0
+	for i, w := range words[:len(words)-1] {
+		pos += len(w)
+		if !bestBoundaries[pos] {
+			ret.Dubious = append(ret.Dubious, &DubiousBoundary{Pos: pos, Kind: ExtraBoundary, Word: words[i+1]})
+		}
+	}
+
+	sort.SliceStable(ret.Dubious, func(i, j int) bool {
+		return ret.Dubious[i].Pos < ret.Dubious[j].Pos
+	})
+
+	if best.Tag() != ret.HashTag.Tag() && best.Score() >= ret.HashTag.Score()*margin {
+		ret.Suggestion = best
+	}
+
+	return ret, nil
+}
+
+// Check splits a hashtag written in CamelCase into its words and checks that segmentation.
+func (c *Completer) Check(tag string, margin float64) (*CheckResult, error) {
+	tag = strings.TrimPrefix(tag, "#")
+	if len(tag) > MaxInputLength {
+		return nil, fmt.Errorf("input is longer than %d characters", MaxInputLength)
+	}
+	if tag == "" {
+		return nil, fmt.Errorf("empty hashtag")
+	}
+
+	lower := strings.ToLower(tag)
+	words := SplitCamelCase(tag)
+	if len(lower) != len(tag) {
+		// some runes, such as the kelvin sign, are shorter once lowercased: the words
+		// are lowercased as well, to be split at the positions of the lowercased tag
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+	}
+
+	sm := c.ComputeStringMatches(lower)
+	return sm.CheckSegmentation(words, margin)
+}
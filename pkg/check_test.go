@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSplitCamelCase(t *testing.T) {
+	assert.Equal(t, []string{"Expert", "Sexchange"}, SplitCamelCase("ExpertSexchange"))
+	assert.Equal(t, []string{"NASA", "Mission", "2019"}, SplitCamelCase("NASAMission2019"))
+	assert.Equal(t, []string{"cleaner"}, SplitCamelCase("cleaner"))
+	assert.Equal(t, []string{"i", "Phone"}, SplitCamelCase("iPhone"))
+}
+
+func TestCheckSegmentation(t *testing.T) {
+	trie := buildTrie([]string{"expert", "experts", "exchange", "sex", "change"})
+	completer := NewCompleter(trie, nil)
+
+	result, err := completer.Check("#ExpertSexchange", DefaultCheckMargin)
+	require.NoError(t, err)
+	assert.Equal(t, "ExpertSexchange", result.HashTag.Tag())
+	assert.Greater(t, result.Rank, 1)
+
+	require.NotNil(t, result.Suggestion)
+	assert.Equal(t, "ExpertsExchange", result.Suggestion.Tag())
+
+	kinds := map[DubiousBoundaryKind]int{}
+	for _, d := range result.Dubious {
+		kinds[d.Kind] = d.Pos
+	}
+	assert.Equal(t, 6, kinds[UnknownWord])
+	assert.Equal(t, 6, kinds[ExtraBoundary])
+	assert.Equal(t, 7, kinds[MissingBoundary])
+}
+
+func TestCheckGoodSegmentation(t *testing.T) {
+	trie := buildTrie([]string{"expert", "experts", "exchange", "sex", "change"})
+	completer := NewCompleter(trie, nil)
+
+	result, err := completer.Check("ExpertsExchange", DefaultCheckMargin)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rank)
+	assert.Nil(t, result.Suggestion)
+	assert.Empty(t, result.Dubious)
+}
+
+func TestCheckShorterLowercase(t *testing.T) {
+	trie := buildTrie([]string{"kelvin", "pen", "island", "is", "land"})
+	completer := NewCompleter(trie, nil)
+
+	// the kelvin sign is 3 bytes long, and lowercases to the 1 byte k
+	result, err := completer.Check("\u212A\u212A\u212APenIsland", DefaultCheckMargin)
+	require.NoError(t, err)
+	assert.Equal(t, "KkkPenIsland", result.HashTag.Tag())
+
+	result, err = completer.Check("\u212AelvinPen", DefaultCheckMargin)
+	require.NoError(t, err)
+	assert.Equal(t, "KelvinPen", result.HashTag.Tag())
+	assert.Equal(t, 1, result.Rank)
+	assert.Empty(t, result.Dubious)
+
+	_, err = completer.ComputeStringMatches("kelvinpen").CheckSegmentation([]string{"\u212Aelvin", "Pen"}, DefaultCheckMargin)
+	assert.Error(t, err)
+}
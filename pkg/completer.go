@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	ahocorasick "github.com/BobuSumisu/aho-corasick"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxInputLength is the longest input we are willing to segment.
+// The search is exponential in the worst case, so this is some cheap ass limiting.
+const MaxInputLength = 60
+
+// Completer bundles the dictionary and the word frequencies used to score matches,
+// so that the REST and gRPC frontends share the same matching code.
+type Completer struct {
+	// Dictionary can be changed while the completer is in use
+	Dictionary *Dictionary
+	// Sources contribute the words of the lattice, the first one being the dictionary
+	Sources []MatchSource
+	// Rerankers adjust the suggestions once they are computed, the chain is empty by default
+	Rerankers RerankChain
+	// Profile holds the ranking strategies requests can choose from
+	Profile *ScoringProfile
+	// Feedback boosts the words and the segmentations that users selected
+	Feedback *Feedback
+	// Cache keeps the suggestions of recent inputs, there is no caching if it is nil
+	Cache *SuggestionCache
+	// Users are the personal dictionaries of the users, they can be nil
+	Users *UserDictionaries
+}
+
+func NewCompleter(trie *ahocorasick.Trie, frequency map[string]int) *Completer {
+	return NewDictionaryCompleter(NewDictionary(trie, frequency))
+}
+
+func NewDictionaryCompleter(dictionary *Dictionary) *Completer {
+	return &Completer{
+		Dictionary: dictionary,
+		Sources:    []MatchSource{dictionary},
+		Profile:    NewScoringProfile(),
+		Feedback:   NewFeedback(),
+	}
+}
+
+// Frequency returns the current word frequencies of the dictionary.
+func (c *Completer) Frequency() map[string]int {
+	return c.Dictionary.Frequency()
+}
+
+// Casing returns the canonical casing of the words of the dictionary, see HashTag.RenderCasing.
+func (c *Completer) Casing() map[string]string {
+	return c.Dictionary.Casing()
+}
+
+// AddSource adds a source of candidate words to the lattice.
+func (c *Completer) AddSource(source MatchSource) {
+	c.Sources = append(c.Sources, source)
+}
+
+// ComputeStringMatches runs all the sources over input and returns the scored match lattice.
+func (c *Completer) ComputeStringMatches(input string) *StringMatches {
+	return NewStringMatches(input, MergeMatches(input, c.Sources...))
+}
+
+// ComputeStringMatchesWith computes the match lattice of input with its words scored by strategy.
+// A nil strategy keeps the scores of the sources, see StrategySource.
+func (c *Completer) ComputeStringMatchesWith(input string, strategy *Strategy) *StringMatches {
+	return c.computeMatches(input, SuggestOptions{Strategy: strategy})
+}
+
+// computeMatches computes the match lattice of input with the sources of the completer
+// and the dictionary of options.User, its words scored by options.Strategy.
+func (c *Completer) computeMatches(input string, options SuggestOptions) *StringMatches {
+	sources := c.Sources
+	if options.User != "" && c.Users != nil {
+		source, err := c.Users.Source(options.User)
+		if err != nil {
+			log.Warn().Err(err).Str("user", options.User).Msg("Could not load the dictionary of the user")
+		}
+		if source != nil {
+			sources = append(sources[:len(sources):len(sources)], source)
+		}
+	}
+
+	sm := NewStringMatches(input, MergeMatches(input, sources...))
+	if options.Strategy != nil {
+		sm.RescoreSources(options.Strategy, sources)
+	}
+	return sm
+}
+
+// Strategy looks up a strategy of the scoring profile by name, an empty name
+// returning the default strategy of the profile.
+func (c *Completer) Strategy(name string) (*Strategy, error) {
+	if c.Profile == nil {
+		return NewScoringProfile().Strategy(name)
+	}
+	return c.Profile.Strategy(name)
+}
@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ConstraintsSource is the source of the words to keep that are missing from the lattice.
+const ConstraintsSource = "constraints"
+
+// Constraints restrict the segmentations a request accepts. They are applied to the
+// lattice and during the search, so that the best hashtags are all valid ones.
+type Constraints struct {
+	// MinWordLength is the length under which words are not used, 0 means no minimum
+	MinWordLength int
+	// ShortWords are allowed even if they are shorter than MinWordLength, such as "a" and "i"
+	ShortWords []string
+	// MaxWords is the maximum number of words of a hashtag, 0 means no limit
+	MaxWords int
+	// Keep are the words that are never split when they appear in the input
+	Keep []string
+}
+
+func (c *Constraints) IsEmpty() bool {
+	return c == nil || (c.MinWordLength <= 0 && c.MaxWords <= 0 && len(c.Keep) == 0)
+}
+
+// Allows reports whether word can be used in a hashtag.
+func (c *Constraints) Allows(word string) bool {
+	if c == nil || len(word) >= c.MinWordLength {
+		return true
+	}
+	for _, w := range c.ShortWords {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Constrain prunes the lattice of the words forbidden by constraints, or that would split
+// one of the words to keep. The words to keep are added to the lattice if they are missing,
+// scored with the strategy of sm. The search then honours the maximum number of words.
+func (sm *StringMatches) Constrain(constraints *Constraints, frequency map[string]int) {
+	if constraints.IsEmpty() {
+		return
+	}
+	sm.MaxWords = constraints.MaxWords
+
+	// kept[pos] is true when a word to keep covers both pos-1 and pos,
+	// so that no word can start or end at pos
+	input := sm.String
+	kept := make([]bool, len(input)+1)
+	// keptWords[pos] are the lengths of the words to keep starting at pos
+	keptWords := make(map[int][]int)
+	for _, w := range constraints.Keep {
+		if w == "" {
+			continue
+		}
+		// the words are compared to the input itself, lowercasing it could change the
+		// byte length of some runes and shift the positions of the lattice
+		for pos := 0; pos < len(input); {
+			if n := prefixFold(input[pos:], w); n > 0 {
+				for j := pos + 1; j < pos+n; j++ {
+					kept[j] = true
+				}
+				keptWords[pos] = append(keptWords[pos], n)
+			}
+			_, size := utf8.DecodeRuneInString(input[pos:])
+			pos += size
+		}
+	}
+
+	for pos, ms_ := range sm.AllMatches {
+		allowed := make([]*Match, 0, len(ms_))
+		for _, m := range ms_ {
+			if kept[m.Pos] || kept[m.Pos+len(m.Match)] || !constraints.Allows(m.Match) {
+				continue
+			}
+			allowed = append(allowed, m)
+		}
+
+		for _, n := range keptWords[pos] {
+			if kept[pos] || kept[pos+n] {
+				// overlaps another word to keep
+				continue
+			}
+			w := sm.String[pos : pos+n]
+			found := false
+			for _, m := range allowed {
+				if len(m.Match) == n && strings.EqualFold(m.Match, w) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				allowed = append(allowed, &Match{
+					Match:  w,
+					Pos:    pos,
+					Score:  sm.Strategy.WordScore(strings.ToLower(w), frequency),
+					Source: ConstraintsSource,
+				})
+			}
+		}
+
+		sort.SliceStable(allowed, func(i, j int) bool {
+			return allowed[i].Score > allowed[j].Score
+		})
+		sm.AllMatches[pos] = allowed
+	}
+}
+
+// prefixFold returns the length in bytes of the prefix of s that is equal to w ignoring
+// case, 0 if s doesn't start with w.
+func prefixFold(s string, w string) int {
+	i := 0
+	for _, r := range w {
+		if i >= len(s) {
+			return 0
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if !equalFoldRune(c, r) {
+			return 0
+		}
+		i += size
+	}
+	return i
+}
+
+func equalFoldRune(a rune, b rune) bool {
+	if a == b {
+		return true
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
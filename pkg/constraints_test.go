@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func suggestConstrained(completer *Completer, input string, count int, constraints *Constraints) []string {
+	suggestions := completer.Suggest(input, SuggestOptions{Count: count, Constraints: constraints})
+	tags := make([]string, len(suggestions.HashTags))
+	for i, ht := range suggestions.HashTags {
+		tags[i] = ht.Tag()
+	}
+	return tags
+}
+
+func TestConstraintsMinWordLength(t *testing.T) {
+	completer := NewCompleter(buildComplexTrie(), nil)
+	assert.Equal(t,
+		[]string{"CleanerThisIsA", "CleanerThisISA", "CleanerThIsIsA", "CLeanerThisIsA"},
+		suggestConstrained(completer, "cleanerthisisa", 4, nil))
+
+	// the single letters of the alphabet are gone, but the top 4 is still full
+	assert.Equal(t,
+		[]string{"CleanerThisIsA", "CleanerThIsIsA", "CleanErThisIsA", "CleanErThIsIsA"},
+		suggestConstrained(completer, "cleanerthisisa", 4, &Constraints{MinWordLength: 2, ShortWords: []string{"a", "i"}}))
+
+	assert.Empty(t, suggestConstrained(completer, "cleanerthisisa", 4, &Constraints{MinWordLength: 2}))
+}
+
+func TestConstraintsMaxWords(t *testing.T) {
+	completer := NewCompleter(buildComplexTrie(), nil)
+	assert.Equal(t,
+		[]string{"CleanerThisIsA"},
+		suggestConstrained(completer, "cleanerthisisa", 4, &Constraints{MaxWords: 4}))
+	assert.Equal(t,
+		[]string{"CleanerThisIsA", "CleanerThisISA", "CleanerThIsIsA", "CLeanerThisIsA"},
+		suggestConstrained(completer, "cleanerthisisa", 4, &Constraints{MaxWords: 5}))
+	assert.Empty(t, suggestConstrained(completer, "cleanerthisisa", 4, &Constraints{MaxWords: 3}))
+
+	for _, tag := range suggestConstrained(completer, "cleanerthisisa", 0, &Constraints{MaxWords: 6}) {
+		assert.LessOrEqual(t, len(SplitCamelCase(tag)), 6, tag)
+	}
+}
+
+func TestConstraintsKeep(t *testing.T) {
+	completer := NewCompleter(buildComplexTrie(), nil)
+
+	// leaner can be kept whole inside cleaner, or on its own
+	assert.Equal(t,
+		[]string{"CleanerThisIsA", "CleanerThisISA", "CleanerThIsIsA", "CLeanerThisIsA", "CleanerThIsISA"},
+		suggestConstrained(completer, "cleanerthisisa", 5, &Constraints{Keep: []string{"leaner"}}))
+
+	// words to keep that are not in the dictionary are added to the lattice
+	suggestions := completer.Suggest("cleanerthisisa", SuggestOptions{Count: 1, Constraints: &Constraints{Keep: []string{"ISA"}}})
+	assert.Equal(t, "CleanerThisIsa", suggestions.HashTags[0].Tag())
+	found := false
+	for _, m := range suggestions.Matches {
+		if m.Source == ConstraintsSource {
+			assert.Equal(t, "isa", m.Match)
+			assert.Equal(t, 11, m.Pos)
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestConstraintsKeepNonASCII(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"kelvin", "el", "vin", "pen"}), nil)
+
+	// the kelvin sign is 3 bytes long, its lowercase k only 1
+	suggestions := completer.Suggest("\u212Aelvinpen", SuggestOptions{Constraints: &Constraints{Keep: []string{"kelvin"}}})
+	require.NotEmpty(t, suggestions.HashTags)
+	for _, ht := range suggestions.HashTags {
+		assert.Equal(t, "\u212Aelvin", ht.Words[0])
+	}
+
+	assert.Equal(t, []string{"Pen\u00c9lan"}, suggestConstrained(completer, "pen\u00e9lan", 0, &Constraints{Keep: []string{"\u00c9LAN"}}))
+}
@@ -0,0 +1,223 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Context boosts are multiplicative: a boost of 1.0 doubles the score of a word.
+const (
+	// ContextWordBoost is applied to lattice words that appear in the context
+	ContextWordBoost = 1.0
+	// ContextRelatedBoost is applied to lattice words that share a stem with a context word,
+	// such as "fish" and "fishing"
+	ContextRelatedBoost = 0.5
+	// ContextBigramBoost is applied to the second word of two consecutive hashtag words
+	// that also appear next to each other in the context
+	ContextBigramBoost = 1.0
+
+	// ContextMinWordLength avoids boosting short filler words such as "a" or "is"
+	ContextMinWordLength = 3
+	// contextMinStemLength is the minimum length of the shared prefix for two words to be related
+	contextMinStemLength = 4
+)
+
+type ContextAdjustmentKind string
+
+const (
+	ContextWord    ContextAdjustmentKind = "context-word"
+	ContextRelated ContextAdjustmentKind = "context-related"
+	ContextBigram  ContextAdjustmentKind = "context-bigram"
+)
+
+// ContextAdjustment documents how the context changed the score of a word.
+type ContextAdjustment struct {
+	Pos int
+	// Word is the boosted word, or the two words of a bigram separated by a space
+	Word   string
+	Kind   ContextAdjustmentKind
+	Before float64
+	After  float64
+}
+
+func (a *ContextAdjustment) String() string {
+	return fmt.Sprintf("%s %s (%d): %f -> %f", a.Kind, a.Word, a.Pos, a.Before, a.After)
+}
+
+// Context holds the words of the text surrounding a hashtag, for example the post it
+// appears in, along with the words of its sibling hashtags.
+type Context struct {
+	Words   map[string]bool
+	Bigrams map[string]bool
+}
+
+func (ctx *Context) addWords(words []string) {
+	prev := ""
+	for _, w := range words {
+		w = strings.ToLower(w)
+		if len(w) >= ContextMinWordLength {
+			ctx.Words[w] = true
+		}
+		if prev != "" {
+			ctx.Bigrams[prev+" "+w] = true
+		}
+		prev = w
+	}
+}
+
+// NewContext splits text into words, and segments the sibling hashtags, either using
+// their capitalization or, for lowercase hashtags, their best suggestion.
+func (c *Completer) NewContext(text string, siblings []string) *Context {
+	ctx := &Context{
+		Words:   make(map[string]bool),
+		Bigrams: make(map[string]bool),
+	}
+
+	ctx.addWords(strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}))
+
+	for _, sibling := range siblings {
+		sibling = strings.TrimPrefix(sibling, "#")
+		if sibling == "" {
+			continue
+		}
+		if sibling != strings.ToLower(sibling) || len(sibling) > MaxInputLength {
+			ctx.addWords(SplitCamelCase(sibling))
+			continue
+		}
+		hashTags := c.ComputeStringMatches(sibling).SuggestTopHashtags(1)
+		if len(hashTags) > 0 {
+			ctx.addWords(hashTags[0].Words)
+		} else {
+			ctx.addWords([]string{sibling})
+		}
+	}
+
+	return ctx
+}
+
+// Merge returns a new context containing the words of ctx and of all the others.
+func (ctx *Context) Merge(others ...*Context) *Context {
+	ret := &Context{
+		Words:   make(map[string]bool),
+		Bigrams: make(map[string]bool),
+	}
+	for _, c := range append([]*Context{ctx}, others...) {
+		if c == nil {
+			continue
+		}
+		for w := range c.Words {
+			ret.Words[w] = true
+		}
+		for b := range c.Bigrams {
+			ret.Bigrams[b] = true
+		}
+	}
+	return ret
+}
+
+// IsEmpty returns true if the context can't influence any score.
+func (ctx *Context) IsEmpty() bool {
+	return ctx == nil || (len(ctx.Words) == 0 && len(ctx.Bigrams) == 0)
+}
+
+func (ctx *Context) isRelated(word string) bool {
+	if len(word) < contextMinStemLength {
+		return false
+	}
+	for w := range ctx.Words {
+		if len(w) >= contextMinStemLength && (strings.HasPrefix(w, word) || strings.HasPrefix(word, w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyContext boosts the lattice words that appear in, or are related to, the context.
+// It has to be called before computing the hashtags, as the search explores
+// the highest scoring matches first.
+func (sm *StringMatches) ApplyContext(ctx *Context) []*ContextAdjustment {
+	ret := make([]*ContextAdjustment, 0)
+	if ctx.IsEmpty() {
+		return ret
+	}
+
+	for _, ms_ := range sm.AllMatches {
+		for _, m := range ms_ {
+			boost, kind := 0.0, ContextAdjustmentKind("")
+			if len(m.Match) >= ContextMinWordLength && ctx.Words[m.Match] {
+				boost, kind = ContextWordBoost, ContextWord
+			} else if ctx.isRelated(m.Match) {
+				boost, kind = ContextRelatedBoost, ContextRelated
+			} else {
+				continue
+			}
+
+			before := m.Score
+			m.Score *= 1 + boost
+			ret = append(ret, &ContextAdjustment{
+				Pos:    m.Pos,
+				Word:   m.Match,
+				Kind:   kind,
+				Before: before,
+				After:  m.Score,
+			})
+		}
+
+		sort.Slice(ms_, func(i, j int) bool {
+			return ms_[i].Score > ms_[j].Score
+		})
+	}
+
+	return ret
+}
+
+// AdjustHashTags boosts the consecutive words of each hashtag that also appear next
+// to each other in the context, and sorts the hashtags again.
+func (ctx *Context) AdjustHashTags(hashTags []*HashTag) []*ContextAdjustment {
+	ret := make([]*ContextAdjustment, 0)
+	if ctx.IsEmpty() || len(ctx.Bigrams) == 0 {
+		return ret
+	}
+
+	seen := make(map[string]bool)
+	for idx, ht := range hashTags {
+		var scores []float64
+		pos := len(ht.Words[0])
+		for i := 1; i < len(ht.Words); i++ {
+			bigram := strings.ToLower(ht.Words[i-1] + " " + ht.Words[i])
+			if ctx.Bigrams[bigram] {
+				if scores == nil {
+					// the scores slice may be shared with other hashtags
+					scores = make([]float64, len(ht.Scores))
+					copy(scores, ht.Scores)
+				}
+				before := scores[i]
+				scores[i] *= 1 + ContextBigramBoost
+				// the same bigram shows up in many hashtags, only document it once
+				key := fmt.Sprintf("%d %s", pos, bigram)
+				if !seen[key] {
+					seen[key] = true
+					ret = append(ret, &ContextAdjustment{
+						Pos:    pos,
+						Word:   bigram,
+						Kind:   ContextBigram,
+						Before: before,
+						After:  scores[i],
+					})
+				}
+			}
+			pos += len(ht.Words[i])
+		}
+		if scores != nil {
+			hashTags[idx] = &HashTag{Words: ht.Words, Scores: scores, Separators: ht.Separators, Strategy: ht.Strategy}
+		}
+	}
+
+	SortHashTags(hashTags)
+
+	return ret
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestContextWords(t *testing.T) {
+	completer := NewCompleter(buildTrie([]string{"sex", "change"}), nil)
+	ctx := completer.NewContext("A post about the sex-change of fish", []string{"#FishFacts", "sexchange"})
+
+	assert.True(t, ctx.Words["post"])
+	assert.False(t, ctx.Words["a"])
+	assert.True(t, ctx.Words["facts"])
+	assert.True(t, ctx.Bigrams["sex change"])
+	assert.True(t, ctx.Bigrams["fish facts"])
+}
+
+func TestApplyContext(t *testing.T) {
+	completer := NewCompleter(buildTrie([]string{"expert", "experts", "exchange", "sex", "change"}), nil)
+
+	matches := completer.ComputeStringMatches("expertsexchange")
+	hashTags := matches.SuggestHashtags()
+	require.NotEmpty(t, hashTags)
+	assert.Equal(t, "ExpertsExchange", hashTags[0].Tag())
+
+	ctx := completer.NewContext("an expert on fish that change their sex change", nil)
+	matches = completer.ComputeStringMatches("expertsexchange")
+	adjustments := matches.ApplyContext(ctx)
+	hashTags = matches.SuggestHashtags()
+	adjustments = append(adjustments, ctx.AdjustHashTags(hashTags)...)
+
+	require.NotEmpty(t, hashTags)
+	assert.Equal(t, "ExpertSexChange", hashTags[0].Tag())
+
+	kinds := map[string]ContextAdjustmentKind{}
+	for _, a := range adjustments {
+		kinds[a.Word] = a.Kind
+		assert.Greater(t, a.After, a.Before)
+	}
+	assert.Equal(t, ContextWord, kinds["expert"])
+	assert.Equal(t, ContextRelated, kinds["experts"])
+	assert.Equal(t, ContextBigram, kinds["sex change"])
+}
@@ -0,0 +1,205 @@
+package pkg
+
+import (
+	"sort"
+)
+
+const (
+	// datFree marks the unused slots of a double-array trie
+	datFree = -1
+	// datRoot is the check of the root, which has no parent
+	datRoot = -2
+)
+
+// DoubleArrayTrie is a compact trie of byte strings. The children of node s are at
+// base[s]+c for each byte c, and check[t] holds the parent of node t, so that a transition
+// exists if check[base[s]+c] == s. The root is node 0.
+type DoubleArrayTrie struct {
+	base  []int32
+	check []int32
+	// terminal is a bitset of the nodes ending a word
+	terminal []uint64
+}
+
+// BuildDoubleArrayTrie builds the trie of words, which don't need to be sorted or unique.
+func BuildDoubleArrayTrie(words []string) *DoubleArrayTrie {
+	sorted := make([]string, 0, len(words))
+	for _, w := range words {
+		if w != "" {
+			sorted = append(sorted, w)
+		}
+	}
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, w := range sorted {
+		if i == 0 || w != sorted[i-1] {
+			unique = append(unique, w)
+		}
+	}
+
+	b := &datBuilder{d: &DoubleArrayTrie{}}
+	b.grow(1024)
+	b.d.check[0] = datRoot
+	b.build(unique)
+	b.trim()
+	return b.d
+}
+
+type datBuilder struct {
+	d *DoubleArrayTrie
+	// nextCheck is where the search for free slots starts, it moves forward
+	// once the slots before it are mostly used
+	nextCheck int32
+}
+
+func (b *datBuilder) grow(size int) {
+	if size <= len(b.d.check) {
+		return
+	}
+	n := len(b.d.check) * 2
+	if n < size {
+		n = size
+	}
+	base := make([]int32, n)
+	copy(base, b.d.base)
+	check := make([]int32, n)
+	copy(check, b.d.check)
+	for i := len(b.d.check); i < n; i++ {
+		check[i] = datFree
+	}
+	terminal := make([]uint64, (n+63)/64)
+	copy(terminal, b.d.terminal)
+	b.d.base, b.d.check, b.d.terminal = base, check, terminal
+}
+
+// trim drops the free slots at the end of the arrays.
+func (b *datBuilder) trim() {
+	n := len(b.d.check)
+	for n > 1 && b.d.check[n-1] == datFree {
+		n--
+	}
+	b.d.base = b.d.base[:n:n]
+	b.d.check = b.d.check[:n:n]
+	terminal := make([]uint64, (n+63)/64)
+	copy(terminal, b.d.terminal)
+	b.d.terminal = terminal
+}
+
+type datNode struct {
+	node   int32
+	lo, hi int
+	depth  int
+}
+
+// build places the nodes breadth first, each node covering the words of
+// sorted[lo:hi] that share the prefix of length depth leading to it.
+func (b *datBuilder) build(sorted []string) {
+	queue := []datNode{{node: 0, lo: 0, hi: len(sorted), depth: 0}}
+	labels := make([]int32, 0, 256)
+	ranges := make([]int, 0, 257)
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		lo := n.lo
+		if lo < n.hi && len(sorted[lo]) == n.depth {
+			b.d.terminal[n.node>>6] |= 1 << (n.node & 63)
+			lo++
+		}
+		if lo == n.hi {
+			continue
+		}
+
+		labels, ranges = labels[:0], ranges[:0]
+		for i := lo; i < n.hi; i++ {
+			c := int32(sorted[i][n.depth])
+			if len(labels) == 0 || labels[len(labels)-1] != c {
+				labels = append(labels, c)
+				ranges = append(ranges, i)
+			}
+		}
+		ranges = append(ranges, n.hi)
+
+		base := b.findBase(labels)
+		b.d.base[n.node] = base
+		for _, c := range labels {
+			b.d.check[base+c] = n.node
+		}
+		for i, c := range labels {
+			queue = append(queue, datNode{node: base + c, lo: ranges[i], hi: ranges[i+1], depth: n.depth + 1})
+		}
+	}
+}
+
+// findBase returns a base at which all the labels fall on free slots.
+func (b *datBuilder) findBase(labels []int32) int32 {
+	first := labels[0]
+	pos := b.nextCheck
+	if pos < first {
+		pos = first
+	}
+	start, used := pos, int32(0)
+	for ; ; pos++ {
+		b.grow(int(pos) + 257)
+		if b.d.check[pos] != datFree {
+			used++
+			continue
+		}
+		base := pos - first
+		ok := true
+		for _, c := range labels[1:] {
+			if b.d.check[base+c] != datFree {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			// skip the slots before pos from now on if they are nearly all used
+			if float64(used) >= 0.95*float64(pos-start+1) {
+				b.nextCheck = pos
+			}
+			return base
+		}
+	}
+}
+
+func (d *DoubleArrayTrie) isTerminal(s int32) bool {
+	return d.terminal[s>>6]&(1<<(s&63)) != 0
+}
+
+func (d *DoubleArrayTrie) MatchWords(input string, fn func(pos int, word string)) {
+	n := int32(len(d.check))
+	for i := 0; i < len(input); i++ {
+		s := int32(0)
+		for j := i; j < len(input); j++ {
+			t := d.base[s] + int32(input[j])
+			if t >= n || d.check[t] != s {
+				break
+			}
+			s = t
+			if d.isTerminal(s) {
+				fn(i, input[i:j+1])
+			}
+		}
+	}
+}
+
+// Contains reports whether word is in the trie.
+func (d *DoubleArrayTrie) Contains(word string) bool {
+	n := int32(len(d.check))
+	s := int32(0)
+	for i := 0; i < len(word); i++ {
+		t := d.base[s] + int32(word[i])
+		if t >= n || d.check[t] != s {
+			return false
+		}
+		s = t
+	}
+	return word != "" && d.isTerminal(s)
+}
+
+// Size returns the number of bytes of the arrays of the trie.
+func (d *DoubleArrayTrie) Size() int {
+	return len(d.base)*4 + len(d.check)*4 + len(d.terminal)*8
+}
@@ -0,0 +1,287 @@
+package pkg
+
+import (
+	"fmt"
+	ahocorasick "github.com/BobuSumisu/aho-corasick"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DictionaryStatus tells how a runtime change alters a word of the dictionary.
+type DictionaryStatus string
+
+const (
+	// WordAdded words are matched even if they are not part of the dictionary files
+	WordAdded DictionaryStatus = "added"
+	// WordRemoved words are never matched
+	WordRemoved DictionaryStatus = "removed"
+	// WordReweighted words only have their frequency changed
+	WordReweighted DictionaryStatus = "reweighted"
+)
+
+// DictionaryEntry is a change made to the dictionary while the server is running.
+type DictionaryEntry struct {
+	Word   string           `json:"word"`
+	Status DictionaryStatus `json:"status"`
+	// Frequency replaces the frequency per million of the word when positive
+	Frequency int `json:"frequency,omitempty"`
+}
+
+// Dictionary is the lexicon of a Completer along with the words added, removed and
+// reweighted at runtime. The trie built from the dictionary files is never modified:
+// the added words are matched by a small overlay trie, and every change (or reload of
+// the lexicon) builds a new snapshot of the overlay and the frequencies that is swapped
+// in atomically, so that the requests being served keep the snapshot they started with.
+type Dictionary struct {
+	// mu serializes the changes, the readers only load the current snapshot
+	mu       sync.Mutex
+	snapshot atomic.Pointer[dictionarySnapshot]
+	// store persists the changes, it can be nil
+	store Store
+}
+
+type dictionarySnapshot struct {
+	lexicon *Lexicon
+	entries map[string]DictionaryEntry
+	// overlay matches the added words, it is nil if there are none
+	overlay   WordMatcher
+	frequency map[string]int
+	// revision counts the snapshots swapped in, see Dictionary.Revision
+	revision uint64
+}
+
+func NewDictionary(trie *ahocorasick.Trie, frequency map[string]int) *Dictionary {
+	return NewLexiconDictionary(&Lexicon{Matcher: NewAhoCorasickMatcher(trie), Frequency: frequency})
+}
+
+// NewLexiconDictionary builds the dictionary of lexicon.
+func NewLexiconDictionary(lexicon *Lexicon) *Dictionary {
+	d := &Dictionary{}
+	d.snapshot.Store(&dictionarySnapshot{
+		lexicon:   lexicon,
+		entries:   make(map[string]DictionaryEntry),
+		frequency: lexicon.Frequency,
+	})
+	return d
+}
+
+// LoadDictionary applies the changes recorded in store to lexicon, and records the new ones in it.
+func LoadDictionary(lexicon *Lexicon, store Store) (*Dictionary, error) {
+	d := NewLexiconDictionary(lexicon)
+	entries, err := store.DictionaryEntries()
+	if err != nil {
+		return nil, err
+	}
+	err = d.Apply(entries...)
+	if err != nil {
+		return nil, err
+	}
+	d.store = store
+	return d, nil
+}
+
+func (d *Dictionary) Name() string {
+	return DictionarySource
+}
+
+// StrategyScore scores word with strategy and the frequencies of the dictionary.
+func (d *Dictionary) StrategyScore(strategy *Strategy, word string) float64 {
+	return strategy.WordScore(word, d.Frequency())
+}
+
+// FindMatches matches input against the dictionary trie and the added words.
+func (d *Dictionary) FindMatches(input string) []*Match {
+	snapshot := d.snapshot.Load()
+
+	ret := make([]*Match, 0)
+	add := func(overlay bool) func(pos int, word string) {
+		return func(pos int, word string) {
+			entry, ok := snapshot.entries[word]
+			// added words are found by the overlay, skip them in the base trie
+			if ok && (entry.Status == WordRemoved || (entry.Status == WordAdded) != overlay) {
+				return
+			}
+			ret = append(ret, &Match{
+				Match:  word,
+				Pos:    pos,
+				Score:  WordScore(word, snapshot.frequency),
+				Source: DictionarySource,
+			})
+		}
+	}
+
+	if snapshot.lexicon.Matcher != nil {
+		snapshot.lexicon.Matcher.MatchWords(input, add(false))
+	}
+	if snapshot.overlay != nil {
+		snapshot.overlay.MatchWords(input, add(true))
+	}
+	return ret
+}
+
+// Version returns the version of the current lexicon.
+func (d *Dictionary) Version() string {
+	return d.snapshot.Load().lexicon.Version
+}
+
+// Revision changes each time the lexicon is reloaded or words are changed, unlike
+// Version which only depends on the files.
+func (d *Dictionary) Revision() uint64 {
+	return d.snapshot.Load().revision
+}
+
+// Reload swaps in a new lexicon, keeping the runtime changes.
+func (d *Dictionary) Reload(lexicon *Lexicon) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := d.snapshot.Load()
+	next := &dictionarySnapshot{
+		lexicon:  lexicon,
+		entries:  current.entries,
+		revision: current.revision + 1,
+	}
+	next.build()
+	d.snapshot.Store(next)
+}
+
+// Casing returns the canonical casing of the words of the current lexicon, the embedded
+// list if it has none. The map must not be modified.
+func (d *Dictionary) Casing() map[string]string {
+	casing := d.snapshot.Load().lexicon.Casing
+	if casing == nil {
+		return defaultCasing
+	}
+	return casing
+}
+
+// Frequency returns the word frequencies, including the runtime changes. The map must not be modified.
+func (d *Dictionary) Frequency() map[string]int {
+	return d.snapshot.Load().frequency
+}
+
+// Entries returns the runtime changes, sorted by word.
+func (d *Dictionary) Entries() []DictionaryEntry {
+	snapshot := d.snapshot.Load()
+	ret := make([]DictionaryEntry, 0, len(snapshot.entries))
+	for _, e := range snapshot.entries {
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Word < ret[j].Word
+	})
+	return ret
+}
+
+// Apply records the changes and swaps in a dictionary including them. A later change of
+// a word replaces the previous one.
+func (d *Dictionary) Apply(changes ...DictionaryEntry) error {
+	entries := make([]DictionaryEntry, len(changes))
+	for i, e := range changes {
+		e.Word = strings.ToLower(strings.TrimSpace(e.Word))
+		if e.Word == "" {
+			return fmt.Errorf("empty word in dictionary change")
+		}
+		switch e.Status {
+		case WordAdded, WordRemoved:
+		case WordReweighted:
+			if e.Frequency <= 0 {
+				return fmt.Errorf("missing frequency to reweight %s", e.Word)
+			}
+		default:
+			return fmt.Errorf("unknown dictionary change %s for %s", e.Status, e.Word)
+		}
+		entries[i] = e
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := d.snapshot.Load()
+	next := &dictionarySnapshot{
+		lexicon:  current.lexicon,
+		entries:  make(map[string]DictionaryEntry, len(current.entries)+len(entries)),
+		revision: current.revision + 1,
+	}
+	for w, e := range current.entries {
+		next.entries[w] = e
+	}
+	for i, e := range entries {
+		if previous, ok := next.entries[e.Word]; ok && e.Status == WordReweighted && previous.Status == WordAdded {
+			// reweighting an added word keeps it added
+			e.Status = WordAdded
+		}
+		entries[i] = e
+		next.entries[e.Word] = e
+	}
+	if d.store != nil {
+		// the snapshot is only swapped once all the changes are stored
+		err := d.store.SetDictionaryEntries(entries...)
+		if err != nil {
+			return err
+		}
+	}
+	next.build()
+
+	d.snapshot.Store(next)
+	return nil
+}
+
+// build compiles the overlay trie of the added words and the frequencies of the snapshot.
+func (s *dictionarySnapshot) build() {
+	baseFrequency := s.lexicon.Frequency
+	added := make([]string, 0)
+	reweighted := false
+	for w, e := range s.entries {
+		if e.Status == WordAdded {
+			added = append(added, w)
+		}
+		if e.Status != WordRemoved && e.Frequency > 0 {
+			reweighted = true
+		}
+	}
+
+	if len(added) > 0 {
+		builder := ahocorasick.NewTrieBuilder()
+		builder.AddStrings(added)
+		s.overlay = NewAhoCorasickMatcher(builder.Build())
+	}
+
+	s.frequency = baseFrequency
+	if reweighted {
+		s.frequency = make(map[string]int, len(baseFrequency)+len(s.entries))
+		for w, f := range baseFrequency {
+			s.frequency[w] = f
+		}
+		for w, e := range s.entries {
+			if e.Status != WordRemoved && e.Frequency > 0 {
+				s.frequency[w] = e.Frequency
+			}
+		}
+	}
+}
+
+// AddWords adds words to the dictionary, with the given frequency per million if it is positive.
+func (d *Dictionary) AddWords(frequency int, words ...string) error {
+	entries := make([]DictionaryEntry, len(words))
+	for i, w := range words {
+		entries[i] = DictionaryEntry{Word: w, Status: WordAdded, Frequency: frequency}
+	}
+	return d.Apply(entries...)
+}
+
+// RemoveWords stops matching words, whether they come from the dictionary files or were added.
+func (d *Dictionary) RemoveWords(words ...string) error {
+	entries := make([]DictionaryEntry, len(words))
+	for i, w := range words {
+		entries[i] = DictionaryEntry{Word: w, Status: WordRemoved}
+	}
+	return d.Apply(entries...)
+}
+
+// Reweight changes the frequency per million of a word.
+func (d *Dictionary) Reweight(word string, frequency int) error {
+	return d.Apply(DictionaryEntry{Word: word, Status: WordReweighted, Frequency: frequency})
+}
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDictionaryChanges(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"fedi", "verse", "toot"}), nil)
+	sm := completer.ComputeStringMatches("fediverse")
+	assert.Equal(t, "FediVerse", sm.SuggestHashtags()[0].Tag())
+
+	require.NoError(t, completer.Dictionary.AddWords(100, "Fediverse"))
+	hashTags := completer.ComputeStringMatches("fediverse").SuggestHashtags()
+	assert.Equal(t, "Fediverse", hashTags[0].Tag())
+	assert.Equal(t, 100, completer.Frequency()["fediverse"])
+
+	require.NoError(t, completer.Dictionary.RemoveWords("fediverse", "verse"))
+	assert.Empty(t, completer.ComputeStringMatches("fediverse").SuggestHashtags())
+	assert.Empty(t, completer.Frequency())
+
+	require.NoError(t, completer.Dictionary.Reweight("toot", 500))
+	assert.Equal(t, 500, completer.Frequency()["toot"])
+	assert.Equal(t, []DictionaryEntry{
+		{Word: "fediverse", Status: WordRemoved},
+		{Word: "toot", Status: WordReweighted, Frequency: 500},
+		{Word: "verse", Status: WordRemoved},
+	}, completer.Dictionary.Entries())
+
+	assert.Error(t, completer.Dictionary.Reweight("toot", 0))
+	assert.Error(t, completer.Dictionary.AddWords(0, " "))
+}
+
+func TestDictionaryReweightAddedWord(t *testing.T) {
+	dictionary := NewDictionary(buildWordsTrie([]string{}), nil)
+	require.NoError(t, dictionary.AddWords(0, "mastodon"))
+	require.NoError(t, dictionary.Reweight("mastodon", 20))
+
+	matches := dictionary.FindMatches("mastodon")
+	require.Len(t, matches, 1)
+	assert.Equal(t, WordScore("mastodon", map[string]int{"mastodon": 20}), matches[0].Score)
+	assert.Equal(t, []DictionaryEntry{{Word: "mastodon", Status: WordAdded, Frequency: 20}}, dictionary.Entries())
+}
+
+func TestLoadDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	store, err := OpenBoltStore(path)
+	require.NoError(t, err)
+	dictionary, err := LoadDictionary(&Lexicon{Matcher: NewAhoCorasickMatcher(buildWordsTrie([]string{"pen", "island"}))}, store)
+	require.NoError(t, err)
+	require.NoError(t, dictionary.AddWords(10, "penisland"))
+	require.NoError(t, dictionary.RemoveWords("island"))
+	require.NoError(t, store.Close())
+
+	store, err = OpenBoltStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	dictionary, err = LoadDictionary(&Lexicon{Matcher: NewAhoCorasickMatcher(buildWordsTrie([]string{"pen", "island"}))}, store)
+	require.NoError(t, err)
+	words := []string{}
+	for _, m := range dictionary.FindMatches("penisland") {
+		words = append(words, m.Match)
+	}
+	assert.ElementsMatch(t, []string{"pen", "penisland"}, words)
+}
+
+// failingStore fails to record dictionary changes, like a full disk.
+type failingStore struct {
+	*MemoryStore
+}
+
+func (s *failingStore) SetDictionaryEntries(entries ...DictionaryEntry) error {
+	return fmt.Errorf("disk full")
+}
+
+func TestDictionaryStoreFailure(t *testing.T) {
+	dictionary, err := LoadDictionary(&Lexicon{Matcher: NewAhoCorasickMatcher(buildWordsTrie([]string{"pen", "island"}))}, &failingStore{NewMemoryStore()})
+	require.NoError(t, err)
+	assert.Error(t, dictionary.AddWords(10, "penisland", "toot"))
+	assert.Empty(t, dictionary.Entries())
+	assert.Len(t, dictionary.FindMatches("penisland"), 2)
+}
+
+func TestDictionaryConcurrentChanges(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"who", "represents"}), nil)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, completer.Dictionary.AddWords(j, "whorepresents"))
+				assert.NoError(t, completer.Dictionary.RemoveWords("whorepresents"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NotEmpty(t, completer.Suggest("whorepresents", SuggestOptions{}).HashTags)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,132 @@
+package pkg
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+)
+
+//go:embed data/tlds.txt
+var tldsFile string
+
+//go:embed data/subdomains.txt
+var subdomainsFile string
+
+var (
+	topLevelDomains = loadWordList(tldsFile)
+	subdomains      = loadWordList(subdomainsFile)
+)
+
+func loadWordList(s string) map[string]bool {
+	ret := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ret[strings.ToLower(line)] = true
+	}
+	return ret
+}
+
+// URL is a domain name or URL split into the parts relevant for segmentation.
+type URL struct {
+	// Scheme includes the trailing ://, and is empty if the input has no scheme
+	Scheme string
+	Host   string
+	// HostOffset is the byte position of Host in the original input
+	HostOffset int
+	// Rest is the path, query and fragment following the host
+	Rest string
+}
+
+// ParseURL splits a domain name or URL into its scheme, host and the rest.
+func ParseURL(s string) *URL {
+	ret := &URL{}
+	if idx := strings.Index(s, "://"); idx >= 0 {
+		ret.Scheme = s[:idx+3]
+		s = s[idx+3:]
+	}
+	ret.HostOffset = len(ret.Scheme)
+
+	end := strings.IndexAny(s, "/?#")
+	if end < 0 {
+		end = len(s)
+	}
+	ret.Host, ret.Rest = s[:end], s[end:]
+
+	return ret
+}
+
+// FixedLabels returns, for each dot-separated label of the host, whether it is
+// a well known top level domain or subdomain, which are not segmented.
+func (u *URL) FixedLabels() []bool {
+	labels := strings.Split(strings.ToLower(u.Host), ".")
+	fixed := make([]bool, len(labels))
+
+	// find the longest known suffix, such as co.uk, but always leave one label
+	// for the registered name
+	for i := 1; i < len(labels); i++ {
+		if topLevelDomains[strings.Join(labels[i:], ".")] {
+			for j := i; j < len(labels); j++ {
+				fixed[j] = true
+			}
+			break
+		}
+	}
+
+	registered := len(labels) - 1
+	for registered > 0 && fixed[registered] {
+		registered--
+	}
+	for i := 0; i < registered && subdomains[labels[i]]; i++ {
+		fixed[i] = true
+	}
+
+	return fixed
+}
+
+// Pieces splits the URL into the pieces to segment. The scheme, the well known
+// top level domains and subdomains are fixed, while the other labels and the
+// path segments are segmented through the lattice.
+func (u *URL) Pieces() ([]*Piece, string) {
+	ret := make([]*Piece, 0)
+	separator := ""
+
+	if u.Scheme != "" {
+		ret = append(ret, &Piece{Text: strings.TrimSuffix(u.Scheme, "://"), Pos: 0, Fixed: true})
+		separator = "://"
+	}
+
+	fixed := u.FixedLabels()
+	pos := u.HostOffset
+	for i, label := range strings.Split(u.Host, ".") {
+		if i > 0 {
+			separator += "."
+		}
+		if fixed[i] {
+			ret = append(ret, &Piece{Text: label, Pos: pos, Separator: separator, Fixed: true})
+			separator = ""
+		} else {
+			pieces, trailer := SplitPieces(label, pos)
+			if len(pieces) > 0 {
+				pieces[0].Separator = separator + pieces[0].Separator
+				ret = append(ret, pieces...)
+				separator = trailer
+			} else {
+				separator += trailer
+			}
+		}
+		pos += len(label) + 1
+	}
+
+	pieces, trailer := SplitPieces(u.Rest, u.HostOffset+len(u.Host))
+	if len(pieces) > 0 {
+		pieces[0].Separator = separator + pieces[0].Separator
+		ret = append(ret, pieces...)
+		return ret, trailer
+	}
+
+	return ret, separator + trailer
+}
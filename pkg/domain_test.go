@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	u := ParseURL("https://www.expertsexchange.co.uk/blog/whorepresents?x=1")
+	assert.Equal(t, "https://", u.Scheme)
+	assert.Equal(t, "www.expertsexchange.co.uk", u.Host)
+	assert.Equal(t, 8, u.HostOffset)
+	assert.Equal(t, "/blog/whorepresents?x=1", u.Rest)
+	assert.Equal(t, []bool{true, false, true, true}, u.FixedLabels())
+
+	// the registered name is never fixed, even if it is a known word
+	assert.Equal(t, []bool{false, true}, ParseURL("blog.com").FixedLabels())
+}
+
+func TestSuggestDomain(t *testing.T) {
+	completer := NewCompleter(buildTrie([]string{"experts", "exchange", "who", "represents", "whore", "presents"}), nil)
+
+	suggestions := completer.Suggest("www.expertsexchange.com", SuggestOptions{Mode: DomainMode, Count: 3})
+	require.NotEmpty(t, suggestions.HashTags)
+	best := suggestions.HashTags[0]
+	assert.Equal(t, "www.ExpertsExchange.com", best.Tag())
+	assert.Equal(t, "W W W dot experts exchange dot com", best.Spoken())
+
+	suggestions = completer.Suggest("https://whorepresents.com/", SuggestOptions{Mode: DomainMode, Count: 3})
+	require.NotEmpty(t, suggestions.HashTags)
+	assert.Equal(t, "https://WhoRepresents.com/", suggestions.HashTags[0].Tag())
+
+	for _, m := range suggestions.Matches {
+		assert.Equal(t, m.Match, "https://whorepresents.com/"[m.Pos:m.Pos+len(m.Match)])
+	}
+}
@@ -0,0 +1,238 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Selection boosts are multiplicative like the context boosts, and grow with the logarithm
+// of the number of selections so that a few users can't take over the rankings.
+const (
+	// SelectedWordBoost is applied to lattice words for each doubling of the number of
+	// times they were part of a selected suggestion
+	SelectedWordBoost = 0.2
+	// SelectedSegmentationBoost is applied to a suggestion for each doubling of the number
+	// of times it was selected for the same input
+	SelectedSegmentationBoost = 0.5
+)
+
+const SelectedWord ContextAdjustmentKind = "selected-word"
+
+// Feedback counts the suggestions users selected, to boost the words and segmentations
+// they choose. It is safe for concurrent use, and can be nil.
+type Feedback struct {
+	mu            sync.RWMutex
+	words         map[string]int
+	segmentations map[string]map[string]int
+	// revision counts the selections recorded since the feedback was loaded
+	revision uint64
+	// store persists the selections, it can be nil
+	store Store
+}
+
+func NewFeedback() *Feedback {
+	return &Feedback{
+		words:         make(map[string]int),
+		segmentations: make(map[string]map[string]int),
+	}
+}
+
+// LoadFeedback loads the selections recorded in store, and records the new ones in it.
+func LoadFeedback(store Store) (*Feedback, error) {
+	f := NewFeedback()
+	f.store = store
+
+	var err error
+	f.words, err = store.WordCounts()
+	if err != nil {
+		return nil, err
+	}
+	f.segmentations, err = store.SelectionCounts()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// normalizeSelection keeps the lowercase letters and digits of s.
+func normalizeSelection(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func segmentationKey(words []string) string {
+	return strings.ToLower(strings.Join(words, " "))
+}
+
+// Select records that words were chosen as the segmentation of input, and returns how many
+// times they have been chosen for it. The words have to spell out (part of) the input.
+func (f *Feedback) Select(input string, words []string) (int, error) {
+	if f == nil {
+		return 0, errors.New("feedback is disabled")
+	}
+	joined := normalizeSelection(strings.Join(words, ""))
+	if joined == "" || !strings.Contains(normalizeSelection(input), joined) {
+		return 0, fmt.Errorf("%s is not a segmentation of %s", strings.Join(words, " "), input)
+	}
+
+	// the store is safe for concurrent use, persisting the selection before taking the
+	// lock keeps the suggestions that read the counts from waiting for the disk
+	if f.store != nil {
+		_, err := f.store.AddSelection(input, words)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.add(input, words), nil
+}
+
+// SelectTag records a CamelCase hashtag such as "#WhoRepresents" as the segmentation
+// of input, see Select. It returns the words of the hashtag.
+func (f *Feedback) SelectTag(input string, tag string) ([]string, int, error) {
+	words := SplitCamelCase(strings.TrimPrefix(tag, "#"))
+	count, err := f.Select(input, words)
+	return words, count, err
+}
+
+// add counts a selection in memory, the lock has to be held.
+func (f *Feedback) add(input string, words []string) int {
+	f.revision++
+	for _, w := range words {
+		f.words[strings.ToLower(w)]++
+	}
+	input = strings.ToLower(input)
+	if f.segmentations[input] == nil {
+		f.segmentations[input] = make(map[string]int)
+	}
+	key := segmentationKey(words)
+	f.segmentations[input][key]++
+	return f.segmentations[input][key]
+}
+
+// Revision changes each time a selection is recorded.
+func (f *Feedback) Revision() uint64 {
+	if f == nil {
+		return 0
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.revision
+}
+
+// InputRevision changes each time a selection is recorded for input.
+func (f *Feedback) InputRevision(input string) int {
+	if f == nil {
+		return 0
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	ret := 0
+	for _, count := range f.segmentations[strings.ToLower(input)] {
+		ret += count
+	}
+	return ret
+}
+
+// WordCount returns the number of selected suggestions word was part of.
+func (f *Feedback) WordCount(word string) int {
+	if f == nil {
+		return 0
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.words[strings.ToLower(word)]
+}
+
+// SelectionCount returns the number of times words were selected for input.
+func (f *Feedback) SelectionCount(input string, words []string) int {
+	if f == nil {
+		return 0
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.segmentations[strings.ToLower(input)][segmentationKey(words)]
+}
+
+// Selected reports whether a segmentation was selected for input.
+func (f *Feedback) Selected(input string) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.segmentations[strings.ToLower(input)]) > 0
+}
+
+func selectionBoost(boost float64, count int) float64 {
+	return 1 + boost*math.Log2(1+float64(count))
+}
+
+// ApplyFeedback boosts the lattice words that were part of selected suggestions.
+// Like ApplyContext, it has to be called before computing the hashtags.
+func (sm *StringMatches) ApplyFeedback(f *Feedback) []*ContextAdjustment {
+	ret := make([]*ContextAdjustment, 0)
+	if f == nil {
+		return ret
+	}
+
+	for _, ms_ := range sm.AllMatches {
+		for _, m := range ms_ {
+			count := f.WordCount(m.Match)
+			if count == 0 {
+				continue
+			}
+
+			before := m.Score
+			m.Score *= selectionBoost(SelectedWordBoost, count)
+			ret = append(ret, &ContextAdjustment{
+				Pos:    m.Pos,
+				Word:   m.Match,
+				Kind:   SelectedWord,
+				Before: before,
+				After:  m.Score,
+			})
+		}
+
+		sort.Slice(ms_, func(i, j int) bool {
+			return ms_[i].Score > ms_[j].Score
+		})
+	}
+
+	return ret
+}
+
+func (f *Feedback) Name() string {
+	return "selection"
+}
+
+// Rerank boosts the suggestions that were selected for input. The hashtags are not sorted.
+func (f *Feedback) Rerank(input string, hashTags []*HashTag) []*HashTag {
+	if f == nil {
+		return hashTags
+	}
+	for i, ht := range hashTags {
+		count := f.SelectionCount(input, ht.Words)
+		if count == 0 {
+			continue
+		}
+		reason := "selected once"
+		if count > 1 {
+			reason = fmt.Sprintf("selected %d times", count)
+		}
+		hashTags[i] = ht.Reranked(f.Name(), selectionBoost(SelectedSegmentationBoost, count), reason)
+	}
+	return hashTags
+}
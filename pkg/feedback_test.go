@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestFeedbackSelect(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"who", "whore", "represents", "presents", "pen", "island", "penis", "is", "land"}), map[string]int{"whore": 100000})
+	suggestions := completer.Suggest("whorepresents", SuggestOptions{})
+	require.Len(t, suggestions.HashTags, 2)
+	assert.Equal(t, "WhorePresents", suggestions.HashTags[0].Tag())
+
+	count, err := completer.Feedback.Select("whorepresents", []string{"Who", "Represents"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, err = completer.Feedback.Select("whorepresents", []string{"Whore", "Presence"})
+	assert.Error(t, err)
+
+	suggestions = completer.Suggest("whorepresents", SuggestOptions{})
+	require.Len(t, suggestions.HashTags, 2)
+	best := suggestions.HashTags[0]
+	assert.Equal(t, "WhoRepresents", best.Tag())
+	require.Len(t, best.Rerankings, 1)
+	assert.Equal(t, "selection", best.Rerankings[0].Reranker)
+	assert.Equal(t, "selected once", best.Rerankings[0].Reason)
+
+	// the selected words are boosted in other inputs too
+	suggestions = completer.Suggest("penisland", SuggestOptions{})
+	kinds := map[string]ContextAdjustmentKind{}
+	for _, a := range suggestions.Adjustments {
+		kinds[a.Word] = a.Kind
+	}
+	assert.Empty(t, kinds)
+	_, err = completer.Feedback.Select("penisland", []string{"Pen", "Island"})
+	require.NoError(t, err)
+	suggestions = completer.Suggest("penisland", SuggestOptions{})
+	for _, a := range suggestions.Adjustments {
+		kinds[a.Word] = a.Kind
+		assert.Greater(t, a.After, a.Before)
+	}
+	assert.Equal(t, map[string]ContextAdjustmentKind{"pen": SelectedWord, "island": SelectedWord}, kinds)
+	assert.Equal(t, "PenIsland", suggestions.HashTags[0].Tag())
+}
+
+func TestLoadFeedback(t *testing.T) {
+	store, err := OpenBoltStore(filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	feedback, err := LoadFeedback(store)
+	require.NoError(t, err)
+	_, err = feedback.Select("penisland", []string{"Pen", "Island"})
+	require.NoError(t, err)
+	count, err := feedback.Select("penisland", []string{"Pen", "Island"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	feedback, err = LoadFeedback(store)
+	require.NoError(t, err)
+	assert.Equal(t, 2, feedback.SelectionCount("PenIsland", []string{"pen", "island"}))
+	assert.Equal(t, 2, feedback.WordCount("island"))
+	assert.Equal(t, 0, feedback.WordCount("penis"))
+}
+
+// blockingStore waits for release before recording a selection, like a slow disk.
+type blockingStore struct {
+	*MemoryStore
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) AddSelection(input string, words []string) (int, error) {
+	close(s.started)
+	<-s.release
+	return s.MemoryStore.AddSelection(input, words)
+}
+
+func TestFeedbackSelectDoesNotBlockReads(t *testing.T) {
+	store := &blockingStore{MemoryStore: NewMemoryStore(), started: make(chan struct{}), release: make(chan struct{})}
+	feedback, err := LoadFeedback(store)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := feedback.Select("penisland", []string{"Pen", "Island"})
+		assert.NoError(t, err)
+	}()
+	<-store.started
+	// the counts can be read while the selection is being persisted
+	assert.Equal(t, 0, feedback.WordCount("island"))
+	close(store.release)
+	<-done
+	assert.Equal(t, 1, feedback.WordCount("island"))
+}
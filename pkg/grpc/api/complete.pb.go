@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.27.1
+// 	protoc-gen-go v1.28.1
 // 	protoc        v3.19.3
 // source: api/complete.proto
 
@@ -28,6 +28,26 @@ type CompleteRequest struct {
 	Inputs []string `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
 	Count  int32    `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
 	Debug  bool     `protobuf:"varint,3,opt,name=debug,proto3" json:"debug,omitempty"`
+	// text surrounding the hashtags, for example the post they appear in
+	Context string `protobuf:"bytes,4,opt,name=context,proto3" json:"context,omitempty"`
+	// other hashtags of the post
+	Siblings []string `protobuf:"bytes,5,rep,name=siblings,proto3" json:"siblings,omitempty"`
+	// hashtag (the default), handle or domain
+	Mode string `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`
+	// also render each suggestion as SSML
+	Ssml bool `protobuf:"varint,7,opt,name=ssml,proto3" json:"ssml,omitempty"`
+	// rendering styles to return for each suggestion (camel, lower-camel, snake,
+	// kebab, spaced, lower or all)
+	Styles []string `protobuf:"bytes,8,rep,name=styles,proto3" json:"styles,omitempty"`
+	// also return the pruned word lattice of each hashtag
+	Lattice bool `protobuf:"varint,9,opt,name=lattice,proto3" json:"lattice,omitempty"`
+	// ranking strategy of the scoring profile, such as fewest-words, max-frequency,
+	// average-score or log-probability. The profile default is used if empty.
+	Strategy string `protobuf:"bytes,10,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	// restrictions on the segmentations, honoured during the search
+	Constraints *Constraints `protobuf:"bytes,11,opt,name=constraints,proto3" json:"constraints,omitempty"`
+	// user whose personal dictionary adds words to the lattice
+	User string `protobuf:"bytes,12,opt,name=user,proto3" json:"user,omitempty"`
 }
 
 func (x *CompleteRequest) Reset() {
@@ -83,36 +103,940 @@ func (x *CompleteRequest) GetDebug() bool {
 	return false
 }
 
+func (x *CompleteRequest) GetContext() string {
+	if x != nil {
+		return x.Context
+	}
+	return ""
+}
+
+func (x *CompleteRequest) GetSiblings() []string {
+	if x != nil {
+		return x.Siblings
+	}
+	return nil
+}
+
+func (x *CompleteRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *CompleteRequest) GetSsml() bool {
+	if x != nil {
+		return x.Ssml
+	}
+	return false
+}
+
+func (x *CompleteRequest) GetStyles() []string {
+	if x != nil {
+		return x.Styles
+	}
+	return nil
+}
+
+func (x *CompleteRequest) GetLattice() bool {
+	if x != nil {
+		return x.Lattice
+	}
+	return false
+}
+
+func (x *CompleteRequest) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *CompleteRequest) GetConstraints() *Constraints {
+	if x != nil {
+		return x.Constraints
+	}
+	return nil
+}
+
+func (x *CompleteRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
 type CompleteResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Input             string              `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
-	Count             int32               `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
-	Hashtags          []*HashTag          `protobuf:"bytes,3,rep,name=hashtags,proto3" json:"hashtags,omitempty"`
-	Matches           []*AhoCorasickMatch `protobuf:"bytes,4,rep,name=matches,proto3" json:"matches,omitempty"`
-	MatchDurationNs   int64               `protobuf:"varint,5,opt,name=match_duration_ns,json=matchDurationNs,proto3" json:"match_duration_ns,omitempty"`
-	SuggestDurationNs int64               `protobuf:"varint,6,opt,name=suggest_duration_ns,json=suggestDurationNs,proto3" json:"suggest_duration_ns,omitempty"`
+	Input             string               `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Count             int32                `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Hashtags          []*HashTag           `protobuf:"bytes,3,rep,name=hashtags,proto3" json:"hashtags,omitempty"`
+	Matches           []*AhoCorasickMatch  `protobuf:"bytes,4,rep,name=matches,proto3" json:"matches,omitempty"`
+	MatchDurationNs   int64                `protobuf:"varint,5,opt,name=match_duration_ns,json=matchDurationNs,proto3" json:"match_duration_ns,omitempty"`
+	SuggestDurationNs int64                `protobuf:"varint,6,opt,name=suggest_duration_ns,json=suggestDurationNs,proto3" json:"suggest_duration_ns,omitempty"`
+	Adjustments       []*ContextAdjustment `protobuf:"bytes,7,rep,name=adjustments,proto3" json:"adjustments,omitempty"`
+	// instance domain of a handle
+	Domain string `protobuf:"bytes,8,opt,name=domain,proto3" json:"domain,omitempty"`
+	// pruned word lattice of a hashtag, only returned when requested
+	Lattice *Lattice `protobuf:"bytes,9,opt,name=lattice,proto3" json:"lattice,omitempty"`
+	// version of the dictionary and frequency files the suggestions come from
+	LexiconVersion string `protobuf:"bytes,10,opt,name=lexicon_version,json=lexiconVersion,proto3" json:"lexicon_version,omitempty"`
+}
+
+func (x *CompleteResponse) Reset() {
+	*x = CompleteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteResponse) ProtoMessage() {}
+
+func (x *CompleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteResponse.ProtoReflect.Descriptor instead.
+func (*CompleteResponse) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CompleteResponse) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *CompleteResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CompleteResponse) GetHashtags() []*HashTag {
+	if x != nil {
+		return x.Hashtags
+	}
+	return nil
+}
+
+func (x *CompleteResponse) GetMatches() []*AhoCorasickMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *CompleteResponse) GetMatchDurationNs() int64 {
+	if x != nil {
+		return x.MatchDurationNs
+	}
+	return 0
+}
+
+func (x *CompleteResponse) GetSuggestDurationNs() int64 {
+	if x != nil {
+		return x.SuggestDurationNs
+	}
+	return 0
+}
+
+func (x *CompleteResponse) GetAdjustments() []*ContextAdjustment {
+	if x != nil {
+		return x.Adjustments
+	}
+	return nil
+}
+
+func (x *CompleteResponse) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CompleteResponse) GetLattice() *Lattice {
+	if x != nil {
+		return x.Lattice
+	}
+	return nil
+}
+
+func (x *CompleteResponse) GetLexiconVersion() string {
+	if x != nil {
+		return x.LexiconVersion
+	}
+	return ""
+}
+
+type CompleteResponses struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Response []*CompleteResponse `protobuf:"bytes,1,rep,name=response,proto3" json:"response,omitempty"`
+}
+
+func (x *CompleteResponses) Reset() {
+	*x = CompleteResponses{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompleteResponses) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteResponses) ProtoMessage() {}
+
+func (x *CompleteResponses) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteResponses.ProtoReflect.Descriptor instead.
+func (*CompleteResponses) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CompleteResponses) GetResponse() []*CompleteResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+type HashTag struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tag    string    `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Count  int32     `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Score  float64   `protobuf:"fixed64,3,opt,name=score,proto3" json:"score,omitempty"`
+	Words  []string  `protobuf:"bytes,4,rep,name=words,proto3" json:"words,omitempty"`
+	Scores []float64 `protobuf:"fixed64,5,rep,packed,name=scores,proto3" json:"scores,omitempty"`
+	// characters kept around the words of a handle, separators[i] precedes words[i]
+	// and the last entry trails the last word
+	Separators []string `protobuf:"bytes,6,rep,name=separators,proto3" json:"separators,omitempty"`
+	// words separated by spaces, with numbers verbalized, acronyms spelled out
+	// and separators spelled out
+	Spoken string `protobuf:"bytes,7,opt,name=spoken,proto3" json:"spoken,omitempty"`
+	// SSML rendering with say-as and break hints, only set when requested
+	Ssml string `protobuf:"bytes,8,opt,name=ssml,proto3" json:"ssml,omitempty"`
+	// the suggestion rendered in each of the requested styles
+	Styles map[string]string `protobuf:"bytes,9,rep,name=styles,proto3" json:"styles,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// position of each word in the input
+	Spans []*WordSpan `protobuf:"bytes,10,rep,name=spans,proto3" json:"spans,omitempty"`
+	// adjustments of the re-rankers of the deployment
+	Rerankings []*Reranking `protobuf:"bytes,11,rep,name=rerankings,proto3" json:"rerankings,omitempty"`
+}
+
+func (x *HashTag) Reset() {
+	*x = HashTag{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HashTag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashTag) ProtoMessage() {}
+
+func (x *HashTag) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashTag.ProtoReflect.Descriptor instead.
+func (*HashTag) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HashTag) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *HashTag) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *HashTag) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *HashTag) GetWords() []string {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+func (x *HashTag) GetScores() []float64 {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+func (x *HashTag) GetSeparators() []string {
+	if x != nil {
+		return x.Separators
+	}
+	return nil
+}
+
+func (x *HashTag) GetSpoken() string {
+	if x != nil {
+		return x.Spoken
+	}
+	return ""
+}
+
+func (x *HashTag) GetSsml() string {
+	if x != nil {
+		return x.Ssml
+	}
+	return ""
+}
+
+func (x *HashTag) GetStyles() map[string]string {
+	if x != nil {
+		return x.Styles
+	}
+	return nil
+}
+
+func (x *HashTag) GetSpans() []*WordSpan {
+	if x != nil {
+		return x.Spans
+	}
+	return nil
+}
+
+func (x *HashTag) GetRerankings() []*Reranking {
+	if x != nil {
+		return x.Rerankings
+	}
+	return nil
+}
+
+// ContextAdjustment documents how the context of a request changed the score
+// of a word or a bigram.
+type ContextAdjustment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pos    int32   `protobuf:"varint,1,opt,name=pos,proto3" json:"pos,omitempty"`
+	Word   string  `protobuf:"bytes,2,opt,name=word,proto3" json:"word,omitempty"`
+	Kind   string  `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	Before float64 `protobuf:"fixed64,4,opt,name=before,proto3" json:"before,omitempty"`
+	After  float64 `protobuf:"fixed64,5,opt,name=after,proto3" json:"after,omitempty"`
+}
+
+func (x *ContextAdjustment) Reset() {
+	*x = ContextAdjustment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContextAdjustment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContextAdjustment) ProtoMessage() {}
+
+func (x *ContextAdjustment) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContextAdjustment.ProtoReflect.Descriptor instead.
+func (*ContextAdjustment) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ContextAdjustment) GetPos() int32 {
+	if x != nil {
+		return x.Pos
+	}
+	return 0
+}
+
+func (x *ContextAdjustment) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+func (x *ContextAdjustment) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ContextAdjustment) GetBefore() float64 {
+	if x != nil {
+		return x.Before
+	}
+	return 0
+}
+
+func (x *ContextAdjustment) GetAfter() float64 {
+	if x != nil {
+		return x.After
+	}
+	return 0
+}
+
+type AhoCorasickMatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pos   int32   `protobuf:"varint,1,opt,name=pos,proto3" json:"pos,omitempty"`
+	Word  string  `protobuf:"bytes,2,opt,name=word,proto3" json:"word,omitempty"`
+	Score float64 `protobuf:"fixed64,3,opt,name=score,proto3" json:"score,omitempty"`
+	// name of the match source that found the word
+	Source string `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+}
+
+func (x *AhoCorasickMatch) Reset() {
+	*x = AhoCorasickMatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AhoCorasickMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AhoCorasickMatch) ProtoMessage() {}
+
+func (x *AhoCorasickMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AhoCorasickMatch.ProtoReflect.Descriptor instead.
+func (*AhoCorasickMatch) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AhoCorasickMatch) GetPos() int32 {
+	if x != nil {
+		return x.Pos
+	}
+	return 0
+}
+
+func (x *AhoCorasickMatch) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+func (x *AhoCorasickMatch) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *AhoCorasickMatch) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+// RefineRequest either starts a refinement session for input (when session is empty),
+// or locks in the next word of an existing session, given either as the chosen word
+// or as the byte position of the next boundary.
+type RefineRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Session  string `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Input    string `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	Word     string `protobuf:"bytes,3,opt,name=word,proto3" json:"word,omitempty"`
+	Boundary int32  `protobuf:"varint,4,opt,name=boundary,proto3" json:"boundary,omitempty"`
+	Count    int32  `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *RefineRequest) Reset() {
+	*x = RefineRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RefineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefineRequest) ProtoMessage() {}
+
+func (x *RefineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefineRequest.ProtoReflect.Descriptor instead.
+func (*RefineRequest) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RefineRequest) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *RefineRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *RefineRequest) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+func (x *RefineRequest) GetBoundary() int32 {
+	if x != nil {
+		return x.Boundary
+	}
+	return 0
+}
+
+func (x *RefineRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type RefineOption struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Word  string   `protobuf:"bytes,1,opt,name=word,proto3" json:"word,omitempty"`
+	Score float64  `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	Best  *HashTag `protobuf:"bytes,3,opt,name=best,proto3" json:"best,omitempty"`
+}
+
+func (x *RefineOption) Reset() {
+	*x = RefineOption{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RefineOption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefineOption) ProtoMessage() {}
+
+func (x *RefineOption) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefineOption.ProtoReflect.Descriptor instead.
+func (*RefineOption) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RefineOption) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+func (x *RefineOption) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *RefineOption) GetBest() *HashTag {
+	if x != nil {
+		return x.Best
+	}
+	return nil
+}
+
+type RefineResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Session   string          `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Input     string          `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	Words     []string        `protobuf:"bytes,3,rep,name=words,proto3" json:"words,omitempty"`
+	Pos       int32           `protobuf:"varint,4,opt,name=pos,proto3" json:"pos,omitempty"`
+	Remaining string          `protobuf:"bytes,5,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	Done      bool            `protobuf:"varint,6,opt,name=done,proto3" json:"done,omitempty"`
+	Options   []*RefineOption `protobuf:"bytes,7,rep,name=options,proto3" json:"options,omitempty"`
+	Hashtags  []*HashTag      `protobuf:"bytes,8,rep,name=hashtags,proto3" json:"hashtags,omitempty"`
+}
+
+func (x *RefineResponse) Reset() {
+	*x = RefineResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RefineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefineResponse) ProtoMessage() {}
+
+func (x *RefineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefineResponse.ProtoReflect.Descriptor instead.
+func (*RefineResponse) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RefineResponse) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *RefineResponse) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *RefineResponse) GetWords() []string {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+func (x *RefineResponse) GetPos() int32 {
+	if x != nil {
+		return x.Pos
+	}
+	return 0
+}
+
+func (x *RefineResponse) GetRemaining() string {
+	if x != nil {
+		return x.Remaining
+	}
+	return ""
+}
+
+func (x *RefineResponse) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *RefineResponse) GetOptions() []*RefineOption {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *RefineResponse) GetHashtags() []*HashTag {
+	if x != nil {
+		return x.Hashtags
+	}
+	return nil
+}
+
+// WordSpan is the position of a word of a suggestion in the input, in bytes
+// and in characters.
+type WordSpan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Word      string `protobuf:"bytes,1,opt,name=word,proto3" json:"word,omitempty"`
+	Start     int32  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	End       int32  `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	CharStart int32  `protobuf:"varint,4,opt,name=char_start,json=charStart,proto3" json:"char_start,omitempty"`
+	CharEnd   int32  `protobuf:"varint,5,opt,name=char_end,json=charEnd,proto3" json:"char_end,omitempty"`
+}
+
+func (x *WordSpan) Reset() {
+	*x = WordSpan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WordSpan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WordSpan) ProtoMessage() {}
+
+func (x *WordSpan) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WordSpan.ProtoReflect.Descriptor instead.
+func (*WordSpan) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WordSpan) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+func (x *WordSpan) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *WordSpan) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *WordSpan) GetCharStart() int32 {
+	if x != nil {
+		return x.CharStart
+	}
+	return 0
+}
+
+func (x *WordSpan) GetCharEnd() int32 {
+	if x != nil {
+		return x.CharEnd
+	}
+	return 0
+}
+
+// Lattice is the pruned word lattice of an input as a DAG, whose nodes are byte
+// positions in the input and whose edges are words.
+type Lattice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nodes []int32        `protobuf:"varint,1,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+	Edges []*LatticeEdge `protobuf:"bytes,2,rep,name=edges,proto3" json:"edges,omitempty"`
+}
+
+func (x *Lattice) Reset() {
+	*x = Lattice{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Lattice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Lattice) ProtoMessage() {}
+
+func (x *Lattice) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Lattice.ProtoReflect.Descriptor instead.
+func (*Lattice) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Lattice) GetNodes() []int32 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *Lattice) GetEdges() []*LatticeEdge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+type LatticeEdge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From  int32   `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	To    int32   `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+	Word  string  `protobuf:"bytes,3,opt,name=word,proto3" json:"word,omitempty"`
+	Score float64 `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
 }
 
-func (x *CompleteResponse) Reset() {
-	*x = CompleteResponse{}
+func (x *LatticeEdge) Reset() {
+	*x = LatticeEdge{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_complete_proto_msgTypes[1]
+		mi := &file_api_complete_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CompleteResponse) String() string {
+func (x *LatticeEdge) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CompleteResponse) ProtoMessage() {}
+func (*LatticeEdge) ProtoMessage() {}
 
-func (x *CompleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_complete_proto_msgTypes[1]
+func (x *LatticeEdge) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -123,78 +1047,132 @@ func (x *CompleteResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CompleteResponse.ProtoReflect.Descriptor instead.
-func (*CompleteResponse) Descriptor() ([]byte, []int) {
-	return file_api_complete_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use LatticeEdge.ProtoReflect.Descriptor instead.
+func (*LatticeEdge) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *CompleteResponse) GetInput() string {
+func (x *LatticeEdge) GetFrom() int32 {
 	if x != nil {
-		return x.Input
+		return x.From
 	}
-	return ""
+	return 0
 }
 
-func (x *CompleteResponse) GetCount() int32 {
+func (x *LatticeEdge) GetTo() int32 {
 	if x != nil {
-		return x.Count
+		return x.To
 	}
 	return 0
 }
 
-func (x *CompleteResponse) GetHashtags() []*HashTag {
+func (x *LatticeEdge) GetWord() string {
 	if x != nil {
-		return x.Hashtags
+		return x.Word
 	}
-	return nil
+	return ""
 }
 
-func (x *CompleteResponse) GetMatches() []*AhoCorasickMatch {
+func (x *LatticeEdge) GetScore() float64 {
 	if x != nil {
-		return x.Matches
+		return x.Score
 	}
-	return nil
+	return 0
 }
 
-func (x *CompleteResponse) GetMatchDurationNs() int64 {
+// Reranking documents how a re-ranker multiplied the score of a suggestion.
+type Reranking struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reranker string  `protobuf:"bytes,1,opt,name=reranker,proto3" json:"reranker,omitempty"`
+	Factor   float64 `protobuf:"fixed64,2,opt,name=factor,proto3" json:"factor,omitempty"`
+	Reason   string  `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *Reranking) Reset() {
+	*x = Reranking{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_complete_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Reranking) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reranking) ProtoMessage() {}
+
+func (x *Reranking) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reranking.ProtoReflect.Descriptor instead.
+func (*Reranking) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Reranking) GetReranker() string {
 	if x != nil {
-		return x.MatchDurationNs
+		return x.Reranker
 	}
-	return 0
+	return ""
 }
 
-func (x *CompleteResponse) GetSuggestDurationNs() int64 {
+func (x *Reranking) GetFactor() float64 {
 	if x != nil {
-		return x.SuggestDurationNs
+		return x.Factor
 	}
 	return 0
 }
 
-type CompleteResponses struct {
+func (x *Reranking) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Constraints restrict the segmentations of a complete request.
+type Constraints struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Response []*CompleteResponse `protobuf:"bytes,1,rep,name=response,proto3" json:"response,omitempty"`
+	MinWordLength int32    `protobuf:"varint,1,opt,name=min_word_length,json=minWordLength,proto3" json:"min_word_length,omitempty"`
+	ShortWords    []string `protobuf:"bytes,2,rep,name=short_words,json=shortWords,proto3" json:"short_words,omitempty"`
+	MaxWords      int32    `protobuf:"varint,3,opt,name=max_words,json=maxWords,proto3" json:"max_words,omitempty"`
+	Keep          []string `protobuf:"bytes,4,rep,name=keep,proto3" json:"keep,omitempty"`
 }
 
-func (x *CompleteResponses) Reset() {
-	*x = CompleteResponses{}
+func (x *Constraints) Reset() {
+	*x = Constraints{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_complete_proto_msgTypes[2]
+		mi := &file_api_complete_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CompleteResponses) String() string {
+func (x *Constraints) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CompleteResponses) ProtoMessage() {}
+func (*Constraints) ProtoMessage() {}
 
-func (x *CompleteResponses) ProtoReflect() protoreflect.Message {
-	mi := &file_api_complete_proto_msgTypes[2]
+func (x *Constraints) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -205,44 +1183,68 @@ func (x *CompleteResponses) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CompleteResponses.ProtoReflect.Descriptor instead.
-func (*CompleteResponses) Descriptor() ([]byte, []int) {
-	return file_api_complete_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use Constraints.ProtoReflect.Descriptor instead.
+func (*Constraints) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *CompleteResponses) GetResponse() []*CompleteResponse {
+func (x *Constraints) GetMinWordLength() int32 {
 	if x != nil {
-		return x.Response
+		return x.MinWordLength
+	}
+	return 0
+}
+
+func (x *Constraints) GetShortWords() []string {
+	if x != nil {
+		return x.ShortWords
 	}
 	return nil
 }
 
-type HashTag struct {
+func (x *Constraints) GetMaxWords() int32 {
+	if x != nil {
+		return x.MaxWords
+	}
+	return 0
+}
+
+func (x *Constraints) GetKeep() []string {
+	if x != nil {
+		return x.Keep
+	}
+	return nil
+}
+
+// SelectRequest records the suggestion a user chose for an input, given either
+// as its words or as a CamelCase tag.
+type SelectRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Count int32  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Input string   `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Words []string `protobuf:"bytes,2,rep,name=words,proto3" json:"words,omitempty"`
+	Tag   string   `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
 }
 
-func (x *HashTag) Reset() {
-	*x = HashTag{}
+func (x *SelectRequest) Reset() {
+	*x = SelectRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_complete_proto_msgTypes[3]
+		mi := &file_api_complete_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *HashTag) String() string {
+func (x *SelectRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HashTag) ProtoMessage() {}
+func (*SelectRequest) ProtoMessage() {}
 
-func (x *HashTag) ProtoReflect() protoreflect.Message {
-	mi := &file_api_complete_proto_msgTypes[3]
+func (x *SelectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -253,51 +1255,60 @@ func (x *HashTag) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HashTag.ProtoReflect.Descriptor instead.
-func (*HashTag) Descriptor() ([]byte, []int) {
-	return file_api_complete_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use SelectRequest.ProtoReflect.Descriptor instead.
+func (*SelectRequest) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *HashTag) GetName() string {
+func (x *SelectRequest) GetInput() string {
 	if x != nil {
-		return x.Name
+		return x.Input
 	}
 	return ""
 }
 
-func (x *HashTag) GetCount() int32 {
+func (x *SelectRequest) GetWords() []string {
 	if x != nil {
-		return x.Count
+		return x.Words
 	}
-	return 0
+	return nil
 }
 
-type AhoCorasickMatch struct {
+func (x *SelectRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+// SelectResponse returns how many times the suggestion was selected for the input.
+type SelectResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Pos  int32  `protobuf:"varint,1,opt,name=pos,proto3" json:"pos,omitempty"`
-	Word string `protobuf:"bytes,2,opt,name=word,proto3" json:"word,omitempty"`
+	Input string   `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Words []string `protobuf:"bytes,2,rep,name=words,proto3" json:"words,omitempty"`
+	Count int32    `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
 }
 
-func (x *AhoCorasickMatch) Reset() {
-	*x = AhoCorasickMatch{}
+func (x *SelectResponse) Reset() {
+	*x = SelectResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_complete_proto_msgTypes[4]
+		mi := &file_api_complete_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *AhoCorasickMatch) String() string {
+func (x *SelectResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AhoCorasickMatch) ProtoMessage() {}
+func (*SelectResponse) ProtoMessage() {}
 
-func (x *AhoCorasickMatch) ProtoReflect() protoreflect.Message {
-	mi := &file_api_complete_proto_msgTypes[4]
+func (x *SelectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_complete_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -308,72 +1319,220 @@ func (x *AhoCorasickMatch) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AhoCorasickMatch.ProtoReflect.Descriptor instead.
-func (*AhoCorasickMatch) Descriptor() ([]byte, []int) {
-	return file_api_complete_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use SelectResponse.ProtoReflect.Descriptor instead.
+func (*SelectResponse) Descriptor() ([]byte, []int) {
+	return file_api_complete_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *AhoCorasickMatch) GetPos() int32 {
+func (x *SelectResponse) GetInput() string {
 	if x != nil {
-		return x.Pos
+		return x.Input
 	}
-	return 0
+	return ""
 }
 
-func (x *AhoCorasickMatch) GetWord() string {
+func (x *SelectResponse) GetWords() []string {
 	if x != nil {
-		return x.Word
+		return x.Words
 	}
-	return ""
+	return nil
+}
+
+func (x *SelectResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
 }
 
 var File_api_complete_proto protoreflect.FileDescriptor
 
 var file_api_complete_proto_rawDesc = []byte{
 	0x0a, 0x12, 0x61, 0x70, 0x69, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x22, 0x55,
-	0x0a, 0x0f, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x22, 0xce,
+	0x02, 0x0a, 0x0f, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x64, 0x65, 0x62, 0x75, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x05, 0x64, 0x65, 0x62, 0x75, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x73, 0x69, 0x62, 0x6c, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x08, 0x73, 0x69, 0x62, 0x6c, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x6d, 0x6f, 0x64, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x73, 0x73, 0x6d, 0x6c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04,
+	0x73, 0x73, 0x6d, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x73, 0x18, 0x08,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x6c, 0x61, 0x74, 0x74, 0x69, 0x63, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x6c,
+	0x61, 0x74, 0x74, 0x69, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65,
+	0x67, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65,
+	0x67, 0x79, 0x12, 0x37, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e, 0x74,
+	0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65,
+	0x74, 0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x0b,
+	0x63, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x75,
+	0x73, 0x65, 0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22,
+	0xac, 0x03, 0x0a, 0x10, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x2d, 0x0a, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x48, 0x61,
+	0x73, 0x68, 0x54, 0x61, 0x67, 0x52, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67, 0x73, 0x12,
+	0x34, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x41, 0x68, 0x6f, 0x43,
+	0x6f, 0x72, 0x61, 0x73, 0x69, 0x63, 0x6b, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x6d, 0x61,
+	0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x64,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0f, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4e,
+	0x73, 0x12, 0x2e, 0x0a, 0x13, 0x73, 0x75, 0x67, 0x67, 0x65, 0x73, 0x74, 0x5f, 0x64, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11,
+	0x73, 0x75, 0x67, 0x67, 0x65, 0x73, 0x74, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4e,
+	0x73, 0x12, 0x3d, 0x0a, 0x0b, 0x61, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x73,
+	0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74,
+	0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x41, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x6d,
+	0x65, 0x6e, 0x74, 0x52, 0x0b, 0x61, 0x64, 0x6a, 0x75, 0x73, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x2b, 0x0a, 0x07, 0x6c, 0x61, 0x74, 0x74,
+	0x69, 0x63, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63, 0x6f, 0x6d, 0x70,
+	0x6c, 0x65, 0x74, 0x65, 0x2e, 0x4c, 0x61, 0x74, 0x74, 0x69, 0x63, 0x65, 0x52, 0x07, 0x6c, 0x61,
+	0x74, 0x74, 0x69, 0x63, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x65, 0x78, 0x69, 0x63, 0x6f, 0x6e,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x6c, 0x65, 0x78, 0x69, 0x63, 0x6f, 0x6e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x4b,
+	0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65,
+	0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x92, 0x03, 0x0a, 0x07,
+	0x48, 0x61, 0x73, 0x68, 0x54, 0x61, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x67, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x61, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75,
 	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12,
-	0x14, 0x0a, 0x05, 0x64, 0x65, 0x62, 0x75, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05,
-	0x64, 0x65, 0x62, 0x75, 0x67, 0x22, 0xff, 0x01, 0x0a, 0x10, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65,
-	0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e,
-	0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74,
-	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2d, 0x0a, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61,
-	0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c,
-	0x65, 0x74, 0x65, 0x2e, 0x48, 0x61, 0x73, 0x68, 0x54, 0x61, 0x67, 0x52, 0x08, 0x68, 0x61, 0x73,
-	0x68, 0x74, 0x61, 0x67, 0x73, 0x12, 0x34, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73,
-	0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74,
-	0x65, 0x2e, 0x41, 0x68, 0x6f, 0x43, 0x6f, 0x72, 0x61, 0x73, 0x69, 0x63, 0x6b, 0x4d, 0x61, 0x74,
-	0x63, 0x68, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6d,
-	0x61, 0x74, 0x63, 0x68, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x73,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x44, 0x75, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x73, 0x75, 0x67, 0x67, 0x65,
-	0x73, 0x74, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x73, 0x18, 0x06,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x73, 0x75, 0x67, 0x67, 0x65, 0x73, 0x74, 0x44, 0x75, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x73, 0x22, 0x4b, 0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x70, 0x6c,
-	0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x08,
-	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a,
-	0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65,
-	0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x33, 0x0a, 0x07, 0x48, 0x61, 0x73, 0x68, 0x54, 0x61, 0x67, 0x12,
-	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
-	0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x38, 0x0a, 0x10, 0x41, 0x68, 0x6f,
-	0x43, 0x6f, 0x72, 0x61, 0x73, 0x69, 0x63, 0x6b, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x10, 0x0a,
-	0x03, 0x70, 0x6f, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x70, 0x6f, 0x73, 0x12,
-	0x12, 0x0a, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x77,
-	0x6f, 0x72, 0x64, 0x32, 0x50, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12,
-	0x44, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x63, 0x6f,
-	0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74,
-	0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x73, 0x22, 0x00, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
-	0x63, 0x6f, 0x6d, 0x2f, 0x42, 0x6f, 0x62, 0x75, 0x53, 0x75, 0x6d, 0x69, 0x73, 0x75, 0x2f, 0x61,
-	0x68, 0x6f, 0x2d, 0x63, 0x6f, 0x72, 0x61, 0x73, 0x69, 0x63, 0x6b, 0x2f, 0x67, 0x72, 0x70, 0x63,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
+	0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x63, 0x6f, 0x72, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x01, 0x52, 0x06, 0x73, 0x63, 0x6f,
+	0x72, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x6f, 0x72,
+	0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74,
+	0x6f, 0x72, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x70, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x70, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x73,
+	0x73, 0x6d, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x73, 0x6d, 0x6c, 0x12,
+	0x35, 0x0a, 0x06, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1d, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x48, 0x61, 0x73, 0x68, 0x54,
+	0x61, 0x67, 0x2e, 0x53, 0x74, 0x79, 0x6c, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06,
+	0x73, 0x74, 0x79, 0x6c, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x05, 0x73, 0x70, 0x61, 0x6e, 0x73, 0x18,
+	0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65,
+	0x2e, 0x57, 0x6f, 0x72, 0x64, 0x53, 0x70, 0x61, 0x6e, 0x52, 0x05, 0x73, 0x70, 0x61, 0x6e, 0x73,
+	0x12, 0x33, 0x0a, 0x0a, 0x72, 0x65, 0x72, 0x61, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x0b,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e,
+	0x52, 0x65, 0x72, 0x61, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x52, 0x0a, 0x72, 0x65, 0x72, 0x61, 0x6e,
+	0x6b, 0x69, 0x6e, 0x67, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x53, 0x74, 0x79, 0x6c, 0x65, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x22, 0x7b, 0x0a, 0x11, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x41, 0x64, 0x6a, 0x75, 0x73,
+	0x74, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x03, 0x70, 0x6f, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6b,
+	0x69, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x06, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x66, 0x74, 0x65, 0x72,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x61, 0x66, 0x74, 0x65, 0x72, 0x22, 0x66, 0x0a,
+	0x10, 0x41, 0x68, 0x6f, 0x43, 0x6f, 0x72, 0x61, 0x73, 0x69, 0x63, 0x6b, 0x4d, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03,
+	0x70, 0x6f, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x22, 0x85, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x62,
+	0x6f, 0x75, 0x6e, 0x64, 0x61, 0x72, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x62,
+	0x6f, 0x75, 0x6e, 0x64, 0x61, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x5f, 0x0a,
+	0x0c, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a,
+	0x04, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x77, 0x6f, 0x72,
+	0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x62, 0x65, 0x73, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65,
+	0x2e, 0x48, 0x61, 0x73, 0x68, 0x54, 0x61, 0x67, 0x52, 0x04, 0x62, 0x65, 0x73, 0x74, 0x22, 0xfb,
+	0x01, 0x0a, 0x0e, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x69,
+	0x6e, 0x70, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6e, 0x70, 0x75,
+	0x74, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x70, 0x6f, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x6d,
+	0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65,
+	0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x12, 0x30, 0x0a, 0x07, 0x6f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63,
+	0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2d, 0x0a,
+	0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x11, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x48, 0x61, 0x73, 0x68, 0x54,
+	0x61, 0x67, 0x52, 0x08, 0x68, 0x61, 0x73, 0x68, 0x74, 0x61, 0x67, 0x73, 0x22, 0x80, 0x01, 0x0a,
+	0x08, 0x57, 0x6f, 0x72, 0x64, 0x53, 0x70, 0x61, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x77, 0x6f, 0x72,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x03, 0x65, 0x6e, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x68, 0x61, 0x72, 0x5f, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x63, 0x68, 0x61, 0x72, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x72, 0x5f, 0x65, 0x6e, 0x64,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x63, 0x68, 0x61, 0x72, 0x45, 0x6e, 0x64, 0x22,
+	0x4c, 0x0a, 0x07, 0x4c, 0x61, 0x74, 0x74, 0x69, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f,
+	0x64, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x05, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73,
+	0x12, 0x2b, 0x0a, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x4c, 0x61, 0x74, 0x74, 0x69,
+	0x63, 0x65, 0x45, 0x64, 0x67, 0x65, 0x52, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x22, 0x5b, 0x0a,
+	0x0b, 0x4c, 0x61, 0x74, 0x74, 0x69, 0x63, 0x65, 0x45, 0x64, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d,
+	0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x74, 0x6f,
+	0x12, 0x12, 0x0a, 0x04, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x77, 0x6f, 0x72, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x22, 0x57, 0x0a, 0x09, 0x52, 0x65,
+	0x72, 0x61, 0x6e, 0x6b, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x72, 0x61, 0x6e,
+	0x6b, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x72, 0x61, 0x6e,
+	0x6b, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x06, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61,
+	0x73, 0x6f, 0x6e, 0x22, 0x87, 0x01, 0x0a, 0x0b, 0x43, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69,
+	0x6e, 0x74, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6d, 0x69, 0x6e, 0x5f, 0x77, 0x6f, 0x72, 0x64, 0x5f,
+	0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x6d, 0x69,
+	0x6e, 0x57, 0x6f, 0x72, 0x64, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x1f, 0x0a, 0x0b, 0x73,
+	0x68, 0x6f, 0x72, 0x74, 0x5f, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0a, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x57, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x1b, 0x0a, 0x09,
+	0x6d, 0x61, 0x78, 0x5f, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x08, 0x6d, 0x61, 0x78, 0x57, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x65, 0x65,
+	0x70, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x65, 0x65, 0x70, 0x22, 0x4d, 0x0a,
+	0x0d, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69,
+	0x6e, 0x70, 0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61,
+	0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x61, 0x67, 0x22, 0x52, 0x0a, 0x0e,
+	0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69,
+	0x6e, 0x70, 0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x32, 0xce, 0x01, 0x0a, 0x08, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x44, 0x0a,
+	0x08, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x19, 0x2e, 0x63, 0x6f, 0x6d, 0x70,
+	0x6c, 0x65, 0x74, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e,
+	0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x73, 0x22, 0x00, 0x12, 0x3d, 0x0a, 0x06, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x12, 0x17, 0x2e,
+	0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74,
+	0x65, 0x2e, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x12, 0x3d, 0x0a, 0x06, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x12, 0x17, 0x2e, 0x63,
+	0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65,
+	0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x77, 0x65, 0x73, 0x65, 0x6e, 0x2f, 0x6d, 0x61, 0x6a, 0x75, 0x73, 0x63, 0x75, 0x6c, 0x65, 0x2f,
+	0x70, 0x6b, 0x67, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x61, 0x70, 0x69, 0x3b, 0x67, 0x72, 0x70,
+	0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -388,25 +1547,51 @@ func file_api_complete_proto_rawDescGZIP() []byte {
 	return file_api_complete_proto_rawDescData
 }
 
-var file_api_complete_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_complete_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_api_complete_proto_goTypes = []interface{}{
 	(*CompleteRequest)(nil),   // 0: complete.CompleteRequest
 	(*CompleteResponse)(nil),  // 1: complete.CompleteResponse
 	(*CompleteResponses)(nil), // 2: complete.CompleteResponses
 	(*HashTag)(nil),           // 3: complete.HashTag
-	(*AhoCorasickMatch)(nil),  // 4: complete.AhoCorasickMatch
+	(*ContextAdjustment)(nil), // 4: complete.ContextAdjustment
+	(*AhoCorasickMatch)(nil),  // 5: complete.AhoCorasickMatch
+	(*RefineRequest)(nil),     // 6: complete.RefineRequest
+	(*RefineOption)(nil),      // 7: complete.RefineOption
+	(*RefineResponse)(nil),    // 8: complete.RefineResponse
+	(*WordSpan)(nil),          // 9: complete.WordSpan
+	(*Lattice)(nil),           // 10: complete.Lattice
+	(*LatticeEdge)(nil),       // 11: complete.LatticeEdge
+	(*Reranking)(nil),         // 12: complete.Reranking
+	(*Constraints)(nil),       // 13: complete.Constraints
+	(*SelectRequest)(nil),     // 14: complete.SelectRequest
+	(*SelectResponse)(nil),    // 15: complete.SelectResponse
+	nil,                       // 16: complete.HashTag.StylesEntry
 }
 var file_api_complete_proto_depIdxs = []int32{
-	3, // 0: complete.CompleteResponse.hashtags:type_name -> complete.HashTag
-	4, // 1: complete.CompleteResponse.matches:type_name -> complete.AhoCorasickMatch
-	1, // 2: complete.CompleteResponses.response:type_name -> complete.CompleteResponse
-	0, // 3: complete.Complete.Complete:input_type -> complete.CompleteRequest
-	2, // 4: complete.Complete.Complete:output_type -> complete.CompleteResponses
-	4, // [4:5] is the sub-list for method output_type
-	3, // [3:4] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	13, // 0: complete.CompleteRequest.constraints:type_name -> complete.Constraints
+	3,  // 1: complete.CompleteResponse.hashtags:type_name -> complete.HashTag
+	5,  // 2: complete.CompleteResponse.matches:type_name -> complete.AhoCorasickMatch
+	4,  // 3: complete.CompleteResponse.adjustments:type_name -> complete.ContextAdjustment
+	10, // 4: complete.CompleteResponse.lattice:type_name -> complete.Lattice
+	1,  // 5: complete.CompleteResponses.response:type_name -> complete.CompleteResponse
+	16, // 6: complete.HashTag.styles:type_name -> complete.HashTag.StylesEntry
+	9,  // 7: complete.HashTag.spans:type_name -> complete.WordSpan
+	12, // 8: complete.HashTag.rerankings:type_name -> complete.Reranking
+	3,  // 9: complete.RefineOption.best:type_name -> complete.HashTag
+	7,  // 10: complete.RefineResponse.options:type_name -> complete.RefineOption
+	3,  // 11: complete.RefineResponse.hashtags:type_name -> complete.HashTag
+	11, // 12: complete.Lattice.edges:type_name -> complete.LatticeEdge
+	0,  // 13: complete.Complete.Complete:input_type -> complete.CompleteRequest
+	6,  // 14: complete.Complete.Refine:input_type -> complete.RefineRequest
+	14, // 15: complete.Complete.Select:input_type -> complete.SelectRequest
+	2,  // 16: complete.Complete.Complete:output_type -> complete.CompleteResponses
+	8,  // 17: complete.Complete.Refine:output_type -> complete.RefineResponse
+	15, // 18: complete.Complete.Select:output_type -> complete.SelectResponse
+	16, // [16:19] is the sub-list for method output_type
+	13, // [13:16] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_api_complete_proto_init() }
@@ -464,6 +1649,18 @@ func file_api_complete_proto_init() {
 			}
 		}
 		file_api_complete_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ContextAdjustment); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AhoCorasickMatch); i {
 			case 0:
 				return &v.state
@@ -475,6 +1672,126 @@ func file_api_complete_proto_init() {
 				return nil
 			}
 		}
+		file_api_complete_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RefineRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RefineOption); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RefineResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WordSpan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Lattice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LatticeEdge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Reranking); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Constraints); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SelectRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_complete_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SelectResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -482,7 +1799,7 @@ func file_api_complete_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_complete_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
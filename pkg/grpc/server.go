@@ -2,12 +2,258 @@ package grpc
 
 import (
 	"context"
+	"github.com/wesen/majuscule/pkg"
 	grpc "github.com/wesen/majuscule/pkg/grpc/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-type Server struct{}
+type Server struct {
+	grpc.UnimplementedCompleteServer
+
+	completer *pkg.Completer
+	sessions  *pkg.RefineSessions
+}
+
+func NewServer(completer *pkg.Completer, sessions *pkg.RefineSessions) *Server {
+	return &Server{
+		completer: completer,
+		sessions:  sessions,
+	}
+}
+
+func NewHashTag(h *pkg.HashTag) *grpc.HashTag {
+	return &grpc.HashTag{
+		Tag:        h.Tag(),
+		Count:      int32(len(h.Words)),
+		Score:      h.Score(),
+		Words:      h.Words,
+		Scores:     h.Scores,
+		Separators: h.Separators,
+		Spoken:     h.Spoken(),
+		Rerankings: NewRerankings(h.Rerankings),
+	}
+}
+
+func NewRerankings(rerankings []*pkg.Reranking) []*grpc.Reranking {
+	if len(rerankings) == 0 {
+		return nil
+	}
+	ret := make([]*grpc.Reranking, len(rerankings))
+	for i, r := range rerankings {
+		ret[i] = &grpc.Reranking{
+			Reranker: r.Reranker,
+			Factor:   r.Factor,
+			Reason:   r.Reason,
+		}
+	}
+	return ret
+}
+
+// NewConstraints converts the constraints of a request, which can be nil.
+func NewConstraints(c *grpc.Constraints) *pkg.Constraints {
+	if c == nil {
+		return nil
+	}
+	return &pkg.Constraints{
+		MinWordLength: int(c.MinWordLength),
+		ShortWords:    c.ShortWords,
+		MaxWords:      int(c.MaxWords),
+		Keep:          c.Keep,
+	}
+}
+
+func NewWordSpans(spans []*pkg.Span) []*grpc.WordSpan {
+	ret := make([]*grpc.WordSpan, len(spans))
+	for i, s := range spans {
+		ret[i] = &grpc.WordSpan{
+			Word:      s.Word,
+			Start:     int32(s.Start),
+			End:       int32(s.End),
+			CharStart: int32(s.CharStart),
+			CharEnd:   int32(s.CharEnd),
+		}
+	}
+	return ret
+}
+
+// renderOptions are the optional renderings of the suggestions of a complete request.
+type renderOptions struct {
+	ssml   bool
+	styles []pkg.Style
+	// casing is the canonical casing of the lexicon the suggestions were computed with
+	casing map[string]string
+}
+
+func (o renderOptions) newHashTag(h *pkg.HashTag) *grpc.HashTag {
+	ret := NewHashTag(h)
+	if o.ssml {
+		ret.Ssml = h.SSML()
+	}
+	if len(o.styles) > 0 {
+		ret.Styles = make(map[string]string, len(o.styles))
+		for style, s := range h.Styles(o.styles, o.casing) {
+			ret.Styles[string(style)] = s
+		}
+	}
+	return ret
+}
+
+func (s *Server) computeHashtags(input string, debug bool, render renderOptions, options pkg.SuggestOptions) *grpc.CompleteResponse {
+	suggestions := s.completer.Suggest(input, options)
+
+	response := &grpc.CompleteResponse{
+		Input:             input,
+		Count:             int32(options.Count),
+		Domain:            suggestions.Domain,
+		Hashtags:          make([]*grpc.HashTag, 0),
+		MatchDurationNs:   suggestions.MatchDuration.Nanoseconds(),
+		SuggestDurationNs: suggestions.SuggestDuration.Nanoseconds(),
+		LexiconVersion:    suggestions.LexiconVersion,
+	}
+
+	if debug {
+		for _, m := range suggestions.Matches {
+			response.Matches = append(response.Matches, &grpc.AhoCorasickMatch{
+				Pos:    int32(m.Pos),
+				Word:   m.Match,
+				Score:  m.Score,
+				Source: m.Source,
+			})
+		}
+		for _, a := range suggestions.Adjustments {
+			response.Adjustments = append(response.Adjustments, &grpc.ContextAdjustment{
+				Pos:    int32(a.Pos),
+				Word:   a.Word,
+				Kind:   string(a.Kind),
+				Before: a.Before,
+				After:  a.After,
+			})
+		}
+	}
+
+	if suggestions.Lattice != nil {
+		response.Lattice = &grpc.Lattice{
+			Nodes: make([]int32, len(suggestions.Lattice.Nodes)),
+			Edges: make([]*grpc.LatticeEdge, len(suggestions.Lattice.Edges)),
+		}
+		for i, n := range suggestions.Lattice.Nodes {
+			response.Lattice.Nodes[i] = int32(n)
+		}
+		for i, e := range suggestions.Lattice.Edges {
+			response.Lattice.Edges[i] = &grpc.LatticeEdge{
+				From:  int32(e.From),
+				To:    int32(e.To),
+				Word:  e.Word,
+				Score: e.Score,
+			}
+		}
+	}
+
+	for _, h := range suggestions.HashTags {
+		hashtag := render.newHashTag(h)
+		hashtag.Spans = NewWordSpans(h.Spans(input, suggestions.Offset))
+		response.Hashtags = append(response.Hashtags, hashtag)
+	}
+
+	return response
+}
 
 func (s *Server) Complete(ctx context.Context, req *grpc.CompleteRequest) (*grpc.CompleteResponses, error) {
-	// Your implementation here
-	return nil, nil
+	count := int(req.Count)
+	if count <= 0 {
+		count = 5
+	}
+
+	mode, err := pkg.ParseMode(req.Mode)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	styles, err := pkg.ParseStyles(req.Styles)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	strategy, err := s.completer.Strategy(req.Strategy)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	render := renderOptions{ssml: req.Ssml, styles: styles, casing: s.completer.Casing()}
+	options := pkg.SuggestOptions{
+		Mode:        mode,
+		Count:       count,
+		Context:     s.completer.NewContext(req.Context, req.Siblings),
+		Lattice:     req.Lattice,
+		Strategy:    strategy,
+		Constraints: NewConstraints(req.Constraints),
+		User:        req.User,
+	}
+	responses := &grpc.CompleteResponses{
+		Response: make([]*grpc.CompleteResponse, len(req.Inputs)),
+	}
+	for i, input := range req.Inputs {
+		responses.Response[i] = s.computeHashtags(input, req.Debug, render, options)
+	}
+
+	return responses, nil
+}
+
+func (s *Server) Select(ctx context.Context, req *grpc.SelectRequest) (*grpc.SelectResponse, error) {
+	words := req.Words
+	var count int
+	var err error
+	if len(words) > 0 {
+		count, err = s.completer.Feedback.Select(req.Input, words)
+	} else {
+		words, count, err = s.completer.Feedback.SelectTag(req.Input, req.Tag)
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &grpc.SelectResponse{
+		Input: req.Input,
+		Words: words,
+		Count: int32(count),
+	}, nil
+}
+
+func (s *Server) Refine(ctx context.Context, req *grpc.RefineRequest) (*grpc.RefineResponse, error) {
+	count := int(req.Count)
+	if count <= 0 {
+		count = 5
+	}
+
+	session, err := s.sessions.Refine(s.completer, req.Session, req.Input, req.Word, int(req.Boundary))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	state := session.State()
+	response := &grpc.RefineResponse{
+		Session:   session.Token,
+		Input:     session.Matches.String,
+		Words:     state.Words,
+		Pos:       int32(state.Pos),
+		Remaining: state.Remaining,
+		Done:      state.Done,
+	}
+	for _, o := range session.NextWords(count) {
+		best := NewHashTag(o.Best)
+		best.Spans = NewWordSpans(o.Best.Spans(response.Input, 0))
+		response.Options = append(response.Options, &grpc.RefineOption{
+			Word:  o.Word,
+			Score: o.Score,
+			Best:  best,
+		})
+	}
+	for _, h := range session.HashTags(count) {
+		hashtag := NewHashTag(h)
+		hashtag.Spans = NewWordSpans(h.Spans(response.Input, 0))
+		response.Hashtags = append(response.Hashtags, hashtag)
+	}
+
+	return response, nil
 }
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wesen/majuscule/pkg"
+	grpc "github.com/wesen/majuscule/pkg/grpc/api"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestCompleteUser(t *testing.T) {
+	matcher, err := pkg.BuildMatcher(pkg.DoubleArrayMatcherKind, []string{"mast", "odon"})
+	require.NoError(t, err)
+	completer := pkg.NewDictionaryCompleter(pkg.NewLexiconDictionary(&pkg.Lexicon{Matcher: matcher}))
+	completer.Users = pkg.NewUserDictionaries(pkg.NewMemoryStore())
+	require.NoError(t, completer.Users.Add("jane", "mastodon"))
+	server := NewServer(completer, pkg.NewRefineSessions(0))
+
+	// the user survives the wire
+	b, err := proto.Marshal(&grpc.CompleteRequest{Inputs: []string{"mastodon"}, Count: 1, User: "jane"})
+	require.NoError(t, err)
+	req := &grpc.CompleteRequest{}
+	require.NoError(t, proto.Unmarshal(b, req))
+	assert.Equal(t, "jane", req.GetUser())
+
+	responses, err := server.Complete(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Mastodon", responses.Response[0].Hashtags[0].Tag)
+
+	responses, err = server.Complete(context.Background(), &grpc.CompleteRequest{Inputs: []string{"mastodon"}, Count: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "MastOdon", responses.Response[0].Hashtags[0].Tag)
+}
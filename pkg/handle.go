@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Handle is a fediverse user handle such as @janedoe_1990@mastodon.social.
+type Handle struct {
+	Local  string
+	Domain string
+	// Offset is the byte position of Local in the original input
+	Offset int
+}
+
+// ParseHandle strips the leading @ of a handle and splits off its instance domain.
+func ParseHandle(s string) *Handle {
+	offset := 0
+	if strings.HasPrefix(s, "@") {
+		offset = 1
+	}
+	local := s[offset:]
+	domain := ""
+	if idx := strings.Index(local, "@"); idx >= 0 {
+		local, domain = local[:idx], local[idx+1:]
+	}
+
+	return &Handle{
+		Local:  local,
+		Domain: domain,
+		Offset: offset,
+	}
+}
+
+// String renders the handle with its local part replaced by display, for example
+// the Tag() of one of its segmentations.
+func (h *Handle) String(display string) string {
+	if h.Domain == "" {
+		return "@" + display
+	}
+	return "@" + display + "@" + h.Domain
+}
+
+// HandleVariant is a handle proposed for a person's name.
+type HandleVariant struct {
+	Handle string
+	Words  []string
+	// Ambiguous is true if the lowercase handle is segmented differently than the
+	// words it was built from, which means that a screen reader user will have trouble
+	// if the capitalization gets lost.
+	Ambiguous bool
+}
+
+// HandleVariants proposes readable handles for a person's name, such as JaneDoe,
+// Jane_Doe or JDoe for "Jane Doe". Unambiguous variants come first.
+func (c *Completer) HandleVariants(name string) []*HandleVariant {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return []*HandleVariant{}
+	}
+	for i, w := range words {
+		words[i] = capitalize(strings.ToLower(w))
+	}
+
+	first, last := words[0], words[len(words)-1]
+	initial := func(s string) string {
+		return string([]rune(s)[:1])
+	}
+	candidates := [][]string{
+		words,
+	}
+	if len(words) > 1 {
+		candidates = append(candidates,
+			[]string{first, last},
+			[]string{initial(first), last},
+			[]string{first, initial(last)},
+			[]string{last, first},
+		)
+	}
+
+	seen := make(map[string]bool)
+	ret := make([]*HandleVariant, 0)
+	unambiguous := make([]*HandleVariant, 0)
+	for _, ws := range candidates {
+		for _, separator := range []string{"", "_"} {
+			handle := strings.Join(ws, separator)
+			if seen[handle] {
+				continue
+			}
+			seen[handle] = true
+
+			variant := &HandleVariant{
+				Handle: handle,
+				Words:  ws,
+			}
+			hashTags := c.Suggest(strings.ToLower(handle), SuggestOptions{Mode: HandleMode, Count: 1}).HashTags
+			variant.Ambiguous = len(hashTags) == 0 || hashTags[0].Tag() != handle
+
+			if variant.Ambiguous {
+				ret = append(ret, variant)
+			} else {
+				unambiguous = append(unambiguous, variant)
+			}
+		}
+	}
+
+	return append(unambiguous, ret...)
+}
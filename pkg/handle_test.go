@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseHandle(t *testing.T) {
+	h := ParseHandle("@janedoe_1990@mastodon.social")
+	assert.Equal(t, "janedoe_1990", h.Local)
+	assert.Equal(t, "mastodon.social", h.Domain)
+	assert.Equal(t, 1, h.Offset)
+	assert.Equal(t, "@JaneDoe_1990@mastodon.social", h.String("JaneDoe_1990"))
+
+	h = ParseHandle("janedoe")
+	assert.Equal(t, "janedoe", h.Local)
+	assert.Equal(t, "", h.Domain)
+}
+
+func TestSuggestHandle(t *testing.T) {
+	completer := NewCompleter(buildTrie([]string{"jane", "doe", "an"}), nil)
+
+	suggestions := completer.Suggest("@janedoe_1990@mastodon.social", SuggestOptions{Mode: HandleMode, Count: 3})
+	assert.Equal(t, "mastodon.social", suggestions.Domain)
+	require.NotEmpty(t, suggestions.HashTags)
+
+	best := suggestions.HashTags[0]
+	assert.Equal(t, "JaneDoe_1990", best.Tag())
+	assert.Equal(t, []string{"Jane", "Doe", "1990"}, best.Words)
+	assert.Equal(t, []string{"", "", "_", ""}, best.Separators)
+
+	for _, m := range suggestions.Matches {
+		assert.Equal(t, m.Match, "@janedoe_1990@mastodon.social"[m.Pos:m.Pos+len(m.Match)])
+	}
+}
+
+func TestHandleVariants(t *testing.T) {
+	completer := NewCompleter(buildTrie([]string{"jane", "doe", "an"}), nil)
+
+	variants := completer.HandleVariants("Jane Doe")
+	handles := map[string]bool{}
+	for _, v := range variants {
+		handles[v.Handle] = true
+	}
+	assert.True(t, handles["JaneDoe"])
+	assert.True(t, handles["Jane_Doe"])
+	assert.True(t, handles["JDoe"])
+	assert.True(t, handles["DoeJane"])
+	assert.False(t, variants[0].Ambiguous)
+}
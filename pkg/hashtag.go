@@ -2,16 +2,18 @@ package pkg
 
 import (
 	"fmt"
-	ahocorasick "github.com/BobuSumisu/aho-corasick"
-	"math"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Match struct {
 	Match string
 	Pos   int
 	Score float64
+	// Source is the name of the MatchSource that found the match
+	Source string
 }
 
 func (m *Match) String() string {
@@ -21,14 +23,14 @@ func (m *Match) String() string {
 type StringMatches struct {
 	String     string
 	AllMatches [][]*Match
-	cache      [][]*HashTag
+	// Strategy ranks the hashtags, nil being the average-score strategy. See Rescore.
+	Strategy *Strategy
+	// MaxWords is the maximum number of words of the hashtags, 0 means no limit. See Constrain.
+	MaxWords int
 }
 
 func WordScore(word string, frequency map[string]int) float64 {
 	// frequency is frequency / million
-
-	l := float64(len(word))
-
 	freq := 0
 	if frequency != nil {
 		f, ok := frequency[word]
@@ -37,9 +39,11 @@ func WordScore(word string, frequency map[string]int) float64 {
 		}
 	}
 
-	lengthWeight := 1.0
-	frequencyWeight := 800.0
+	return weightedWordScore(word, freq, DefaultLengthWeight, DefaultFrequencyWeight)
+}
 
+func weightedWordScore(word string, freq int, lengthWeight float64, frequencyWeight float64) float64 {
+	l := float64(len(word))
 	freqFactor := (float64(freq) / 1000000.0) * frequencyWeight
 	lengthFactor := l * l * lengthWeight
 	score := lengthFactor + freqFactor
@@ -51,27 +55,70 @@ func NewStringMatches(s string, matches [][]*Match) *StringMatches {
 	// we sort the individual matches to have the longest one first (most salient)
 
 	return &StringMatches{
-		s,
-		matches,
-		make([][]*HashTag, len(s)),
+		String:     s,
+		AllMatches: matches,
 	}
 }
 
-func ComputeMatches(s string, matches []*ahocorasick.Match, frequency map[string]int) [][]*Match {
-	matches_ := make([][]*Match, len(s))
+// Rescore scores the dictionary words of the lattice with strategy, which then ranks
+// the suggested hashtags. The words of the other sources keep their scores.
+func (sm *StringMatches) Rescore(strategy *Strategy, frequency map[string]int) {
+	sm.rescore(strategy, func(m *Match) (float64, bool) {
+		if m.Source != DictionarySource {
+			return 0, false
+		}
+		return strategy.WordScore(m.Match, frequency), true
+	})
+}
 
-	for _, match := range matches {
-		pos := match.Pos()
+// RescoreSources scores the words of the lattice found by the StrategySource of
+// sources with strategy, which then ranks the suggested hashtags. The words of the
+// other sources keep their scores.
+func (sm *StringMatches) RescoreSources(strategy *Strategy, sources []MatchSource) {
+	bySource := make(map[string]StrategySource, len(sources))
+	for _, source := range sources {
+		if s, ok := source.(StrategySource); ok {
+			bySource[s.Name()] = s
+		}
+	}
+	sm.rescore(strategy, func(m *Match) (float64, bool) {
+		s, ok := bySource[m.Source]
+		if !ok {
+			return 0, false
+		}
+		return s.StrategyScore(strategy, m.Match), true
+	})
+}
 
+func (sm *StringMatches) rescore(strategy *Strategy, score func(m *Match) (float64, bool)) {
+	sm.Strategy = strategy
+	for _, ms_ := range sm.AllMatches {
+		for _, m := range ms_ {
+			if s, ok := score(m); ok {
+				m.Score = s
+			}
+		}
+		sort.SliceStable(ms_, func(i, j int) bool {
+			return ms_[i].Score > ms_[j].Score
+		})
+	}
+}
+
+// ComputeMatches groups the words found by matcher in s by position, scored with their frequency.
+func ComputeMatches(s string, matcher WordMatcher, frequency map[string]int) [][]*Match {
+	matches_ := make([][]*Match, len(s))
+
+	matcher.MatchWords(s, func(pos int, word string) {
 		if matches_[pos] == nil {
 			matches_[pos] = make([]*Match, 0)
 		}
 		matches_[pos] = append(matches_[pos], &Match{
-			Match: match.MatchString(),
-			Pos:   int(match.Pos()),
-			Score: WordScore(match.MatchString(), frequency),
+			Match:  word,
+			Pos:    pos,
+			Score:  WordScore(word, frequency),
+			Source: DictionarySource,
 		})
-	}
+	})
 
 	for _, ms_ := range matches_ {
 		sort.Slice(ms_, func(i, j int) bool {
@@ -84,10 +131,30 @@ func ComputeMatches(s string, matches []*ahocorasick.Match, frequency map[string
 type HashTag struct {
 	Words  []string
 	Scores []float64
+	// Separators are the characters kept around the words when segmenting handles or domains.
+	// Separators[i] precedes Words[i], and the last entry trails the last word.
+	// It is nil for plain hashtags.
+	Separators []string
+	// Rerankings are the adjustments of the re-rankers, see RerankChain
+	Rerankings []*Reranking
+	// Strategy combines Scores into Score(), nil being the average-score strategy
+	Strategy *Strategy
 }
 
 func (ht *HashTag) Tag() string {
-	return strings.Join(ht.Words, "")
+	if ht.Separators == nil {
+		return strings.Join(ht.Words, "")
+	}
+
+	var sb strings.Builder
+	for i, w := range ht.Words {
+		sb.WriteString(ht.Separators[i])
+		sb.WriteString(w)
+	}
+	if len(ht.Separators) > len(ht.Words) {
+		sb.WriteString(ht.Separators[len(ht.Words)])
+	}
+	return sb.String()
 }
 
 func NewHashTag(words []string, scores []float64) *HashTag {
@@ -97,14 +164,13 @@ func NewHashTag(words []string, scores []float64) *HashTag {
 	}
 }
 
-// Score computes the score for the hashtag, lower is better
+// Score computes the score for the hashtag, higher is better
 func (ht *HashTag) Score() float64 {
-	// the sum of the scores
-	score := 0.0
-	for _, s := range ht.Scores {
-		score += s
+	score := ht.Strategy.Combine(ht.Scores)
+	for _, r := range ht.Rerankings {
+		score *= r.Factor
 	}
-	return score / float64(len(ht.Scores))
+	return score
 }
 
 func (ht *HashTag) String() string {
@@ -112,13 +178,14 @@ func (ht *HashTag) String() string {
 	for i, score := range ht.Scores {
 		scoresString[i] = fmt.Sprintf("%f", score)
 	}
-	return fmt.Sprintf("%s (%d) [%s]", ht.Tag, ht.Words, strings.Join(scoresString, ","))
+	return fmt.Sprintf("%s (%v) [%s]", ht.Tag(), ht.Words, strings.Join(scoresString, ","))
 }
 
+// AppendMatch returns a new hashtag with match appended, the words of ht are copied.
 func (ht *HashTag) AppendMatch(match string, score float64) *HashTag {
 	return NewHashTag(
-		append(ht.Words, capitalize(match)),
-		append(ht.Scores, score),
+		append(ht.Words[:len(ht.Words):len(ht.Words)], capitalize(match)),
+		append(ht.Scores[:len(ht.Scores):len(ht.Scores)], score),
 	)
 }
 
@@ -128,227 +195,44 @@ func (ht *HashTag) Prepend(match string, score float64) *HashTag {
 		append([]float64{score}, ht.Scores...),
 	)
 }
+
+// AppendMatchWithSuffix returns a new hashtag made of ht, match and suffix, the words of ht are copied.
 func (ht *HashTag) AppendMatchWithSuffix(match string, matchScore float64, suffix *HashTag) *HashTag {
 	return NewHashTag(
-		append(append(ht.Words, capitalize(match)), suffix.Words...),
-		append(append(ht.Scores, matchScore), suffix.Scores...),
+		append(append(ht.Words[:len(ht.Words):len(ht.Words)], capitalize(match)), suffix.Words...),
+		append(append(ht.Scores[:len(ht.Scores):len(ht.Scores)], matchScore), suffix.Scores...),
 	)
 }
 
-type toGoStackEntry struct {
-	prefix      *HashTag
-	matchString string
-	pos         int
-	score       float64
-}
-
-func NewToGoStackEntry(prefix *HashTag, match *Match) *toGoStackEntry {
-	return &toGoStackEntry{
-		prefix,
-		match.Match,
-		match.Pos,
-		match.Score,
-	}
-}
-
-func (e *toGoStackEntry) String() string {
-	return fmt.Sprintf("{prefix: %s, match %s (%d)}", e.prefix, e.matchString, e.pos)
-}
-
-type toGoStack []*toGoStackEntry
-
-func (s *toGoStack) Len() int {
-	return len(*s)
-}
-
-func (s *toGoStack) Push(e *toGoStackEntry) {
-	*s = append(*s, e)
-}
-
-func (s *toGoStack) Pop() *toGoStackEntry {
-	if len(*s) == 0 {
-		return nil
-	}
-
-	ret := (*s)[len(*s)-1]
-	*s = (*s)[:len(*s)-1]
-	return ret
-}
-
-func (sm *StringMatches) createInitialToGoStack() *toGoStack {
-	stack := make(toGoStack, 0)
-
-	// we need to append sm.AllMatches[0] in reverse order to the list,
-	// so that we have the highest weight on top of the stack
-	if len(sm.AllMatches) > 0 {
-		for i := len(sm.AllMatches[0]) - 1; i >= 0; i-- {
-			match := sm.AllMatches[0][i]
-			stack.Push(NewToGoStackEntry(NewHashTag([]string{}, []float64{}), match))
-		}
-	}
-
-	return &stack
-}
-
-type hashTagStep struct {
-	cache         map[int][]*HashTag
-	cacheMaxScore map[int]float64
-	toGoStack     *toGoStack
-	curEntry      *toGoStackEntry
-	curPos        int
-	matchString   string
-	nextPos       int
-}
-
-// ComputeHashTagsIterative is an iterative, non-recursive version of ComputeHashTags
-// which will make it easier to provide bounded depth, hopefully.
-// `maxResults` is the maximum number of results to return.
-//
-// while we are at it, let's introduce a `maxResults` of results to return.
-// we can start cutting of the depth if we have more than maxCount entries in
-// `ret`, because anything that will have a higher score won't make it in there anyway
+// ComputeHashTagsIterative is an iterative, non-recursive version of ComputeHashTags,
+// see ComputeHashTagsIterativeFrom.
 //
 // A maxResults of 0 means no limit
 func (sm *StringMatches) ComputeHashTagsIterative(maxResults int) []*HashTag {
-	// entries in ret should be sorted by Score()
-	ret := make([]*HashTag, 0)
-
-	// we have two stacks, one for how far we are in the string,
-	// and one for the possibilities that we are exploring
-
-	// this is what we have to go on
-	// for each step that we have to do down, we need not just the match to process,
-	// but the history of how we got there. We need a new struct
-	toGo := sm.createInitialToGoStack()
-
-	cache := make(map[int][]*HashTag, len(sm.String))
-
-	// we also store how many words max we have for each cache entry, in case we need to iterate
-	// deeper.
-	//
-	// BRAINSTORM: we also need to store the fact that we might have explored this entry entirely,
-	// instead of having cutoff our search because of a threshold depth.
-	cacheMaxScore := make(map[int]float64, len(sm.String))
-
-	// the maximum number of words new entries should have,
-	// this is the maximum number of words in ret, if ret is bigger than maxResults,
-	// other it is just the length of the string, since each letter can be a single word
-	// TODO: this should actually be the score, but for, score is just word count
-	// set maxScore to infinity
-	maxScore := math.Inf(1)
-
-	recordedSteps := make([]*hashTagStep, 0)
-
-	appendResult := func(cur *toGoStackEntry, suffix *HashTag) {
-		matchString := cur.matchString
-		curPos := cur.pos
-
-		newHashTag := cur.prefix.AppendMatchWithSuffix(matchString, cur.score, suffix)
-		suffixHashTag := suffix.Prepend(capitalize(matchString), cur.score)
-
-		_, ok := cache[curPos]
-		if !ok {
-			cache[curPos] = make([]*HashTag, 0)
-		}
-		// BRAINSTORM: we append this to our cached entry,
-		// but really the cache should only be updated once
-		// we "step back" a level. This might be fine however, since we only
-		// ever go to further suffixes from here
-		cache[curPos] = append(cache[curPos], suffixHashTag)
-
-		// now update the max words count for this position
-		if suffixHashTag.Score() > cacheMaxScore[curPos] {
-			cacheMaxScore[curPos] = suffixHashTag.Score()
-		}
-
-		// insert the new hashtag into ret, at the right position by Score()
-		ret = insertSortedByScore(ret, newHashTag)
-
-		if maxResults > 0 && len(ret) > maxResults {
-			// if we have more than maxResults score, then there is no need to compute matches
-			// that have a higher score than the entry at maxResults
-			maxScore = ret[maxResults-1].Score()
-		}
-	}
-
-	maxLen := 400
-
-	for {
-		if toGo.Len() == 0 {
-			break
-		}
-
-		if len(ret) > maxLen {
-			break
-		}
-
-		// pop off the first of the toGo matches
-		cur := toGo.Pop()
-
-		// BRAINSTORM: what does it mean to explore a pos completely?
-		// it means that the next entry in the toGo is higher, and we haven't
-		// cut off our search. So we need to recognize when we are going back up a level
-		//
-
-		matchString := cur.matchString
-		curPos := cur.pos
-		nextPos := curPos + len(matchString)
+	return sm.ComputeHashTagsIterativeFrom(0, maxResults)
+}
 
-		// record step
-		//recordedStep := recordStep(cache, toGo, cacheMaxScore, cur, curPos, matchString, nextPos)
-		//recordedSteps = append(recordedSteps, recordedStep)
+// ComputeHashTagsIterativeFrom computes the hashtags covering the suffix of the string
+// starting at byte position `pos`, sorted by average score. This is used to refine a
+// hashtag once its first words have been locked in.
+//
+// The segmentations are explored without building any hashtag, only the best
+// `maxResults` are returned as hashtags, all of them if it is 0.
+func (sm *StringMatches) ComputeHashTagsIterativeFrom(pos int, maxResults int) []*HashTag {
+	return sm.computeHashTags(pos, maxResults, nil)
+}
 
-		// we now explore all the suffix matches at the newPos
+func (sm *StringMatches) computeHashTags(pos int, count int, strategy *Strategy) []*HashTag {
+	a := searchArenas.Get().(*searchArena)
+	defer searchArenas.Put(a)
 
-		// if we are at the end of the string, we can now add a new
-		// hashtag to the cache at cur.pos, and add to the results
-		// (sorted by score)
-		if nextPos >= len(sm.String) {
-			appendResult(cur, NewHashTag([]string{}, []float64{}))
-		} else {
-			// we now "recurse" by adding all the matches at the next position to the toGo,
-			// if they could potentially lead to lower scores than maxScore
-
-			// BRAINSTORM: can we reused cached results if their score is lower than maxScore?
-			//
-			// we can definitely have cached entries that have been previously cutoff because
-			// we could have gotten there with a long first match but then many short matches, for example
-			// cleaner12345tombstone where cleaner would be tackled first, but then gotten 5 single letter words
-			// before matching is and great, and potentially only matching tombstone but not tomb, stone.
-			// however, we could, if for some reason "er12345" is a word, get there later with 2 matches
-			// clean, er12345 and thus have enough "headroom" to match tomb, stone.
-			//
-			// I don't think we need to really special case this, because in the case of shorter matches, it
-			// means that the next potential matches are either already cached with a proper maxScore,
-			// or we'd need to recurse into those anyway too.
-			//
-			// this check for maxScore should be done at the beginning of the loop, no need to be clever
-
-			_ = maxScore
-			//if len(cache[nextPos]) > 0 && cacheMaxScore[nextPos] < maxScore {
-			//	// BRAINSTORM: maybe in the first pass I shouldn't worry about the maxScore trick,
-			//	// and get the iterative version correct.
-			//
-			//}
-
-			if len(cache[nextPos]) > 0 {
-				for _, suffixHashTag := range cache[nextPos] {
-					appendResult(cur, suffixHashTag)
-				}
-			} else {
-				// this means we need to recurse in for real now, by adding the matches
-				// to toGo.
-				// append sm.AllMatches[nextPos] in reverse order to have the highest weight on top
-				for i := len(sm.AllMatches[nextPos]) - 1; i >= 0; i-- {
-					match := sm.AllMatches[nextPos][i]
-					newHashTag := cur.prefix.AppendMatch(capitalize(matchString), cur.score)
-					nextToGo := NewToGoStackEntry(newHashTag, match)
-					toGo.Push(nextToGo)
-				}
-			}
-		}
-	}
+	sm.search(a, pos)
+	a.rank(strategy)
+	return a.materialize(count, strategy)
+}
 
+// SortHashTags sorts hashtags by descending Score(), breaking ties on Tag().
+func SortHashTags(ret []*HashTag) {
 	sort.Slice(ret, func(i, j int) bool {
 		// score here could be score of the individual words, but inverse to the total count of words, or something like that ?
 		// or maybe just average score of all words / number of words ?
@@ -358,87 +242,32 @@ func (sm *StringMatches) ComputeHashTagsIterative(maxResults int) []*HashTag {
 			return ret[i].Score() > ret[j].Score()
 		}
 	})
-
-	_ = recordedSteps
-
-	return ret
-
-}
-
-func recordStep(
-	cache map[int][]*HashTag,
-	toGo *toGoStack,
-	cacheMaxScore map[int]float64,
-	cur *toGoStackEntry,
-	curPos int,
-	matchString string,
-	nextPos int,
-) *hashTagStep {
-	cacheCopy := make(map[int][]*HashTag, len(cache))
-	for k, v := range cache {
-		vCopy := make([]*HashTag, len(v))
-		copy(vCopy, v)
-		cacheCopy[k] = vCopy
-	}
-	toGoStackCopy := make(toGoStack, toGo.Len())
-	copy(toGoStackCopy, *toGo)
-
-	cacheMaxScoreCopy := make(map[int]float64, len(cacheMaxScore))
-	for k, v := range cacheMaxScore {
-		cacheMaxScoreCopy[k] = v
-	}
-
-	recordedStep := &hashTagStep{
-		cache:         cacheCopy,
-		cacheMaxScore: cacheMaxScoreCopy,
-		toGoStack:     &toGoStackCopy,
-		curEntry:      cur,
-		curPos:        curPos,
-		matchString:   matchString,
-		nextPos:       nextPos,
-	}
-	return recordedStep
-}
-
-func insertSortedByScore(ret []*HashTag, tag *HashTag) []*HashTag {
-	if len(ret) == 0 {
-		ret = append(ret, tag)
-		return ret
-	}
-
-	// we need to find the right position to insert the tag
-	// we can do a binary search, since the slice is sorted by Score()
-	insertPos := sort.Search(len(ret), func(i int) bool {
-		return ret[i].Score() >= tag.Score()
-	})
-
-	ret = append(ret, nil)
-	copy(ret[insertPos+1:], ret[insertPos:])
-	ret[insertPos] = tag
-
-	return ret
 }
 
-// ComputeHashTags computes the best hashtags starting at a given position
+// ComputeHashTags computes all the hashtags starting at a given position, best first
 func (sm *StringMatches) ComputeHashTags(pos int) []*HashTag {
-	ret := make([]*HashTag, 0)
+	return sm.allHashTags(pos, make(map[int][]*HashTag))
+}
 
+// allHashTags computes the hashtags of ComputeHashTags, memo keeping those of the suffixes
+// already computed
+func (sm *StringMatches) allHashTags(pos int, memo map[int][]*HashTag) []*HashTag {
 	if pos >= len(sm.String) {
 		return []*HashTag{
 			NewHashTag([]string{}, []float64{}),
 		}
 	}
-
-	if sm.cache[pos] != nil {
-		return sm.cache[pos]
+	if ret, ok := memo[pos]; ok {
+		return ret
 	}
 
 	// we go through all the matches at the current position and try to build a hashtag
 	// and then recurse into the suffix
+	ret := make([]*HashTag, 0)
 	for _, match := range sm.AllMatches[pos] {
 		s := match.Match
 
-		for _, suffix := range sm.ComputeHashTags(pos + len(s)) {
+		for _, suffix := range sm.allHashTags(pos+len(s), memo) {
 			// we try to capitalize the first letter of the suffix
 			// and then add it to the current match
 			ret = append(ret, suffix.Prepend(capitalize(s), match.Score))
@@ -449,7 +278,7 @@ func (sm *StringMatches) ComputeHashTags(pos int) []*HashTag {
 		return ret[i].Score() > ret[j].Score()
 	})
 
-	sm.cache[pos] = ret
+	memo[pos] = ret
 
 	return ret
 }
@@ -458,14 +287,67 @@ func capitalize(s string) string {
 	if len(s) == 0 {
 		return ""
 	}
-	return strings.ToUpper(s[:1]) + s[1:]
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
 }
 
 // SuggestHashtags using a DP approach to computing possible hashtags
 // It keeps track of the best result starting at a certain position.
 // A best hashtag is the one that uses the least capitalizations to cover a given area.
 func (sm *StringMatches) SuggestHashtags() []*HashTag {
-	hashTags := sm.ComputeHashTagsIterative(0)
+	return sm.SuggestTopHashtags(0)
+}
+
+// SuggestTopHashtags returns the best count hashtags of SuggestHashtags, all of them if
+// count is 0, without building the others.
+func (sm *StringMatches) SuggestTopHashtags(count int) []*HashTag {
+	return sm.computeHashTags(0, count, sm.Strategy)
+}
+
+// Span is the position of a word of a hashtag in the input it was segmented from.
+type Span struct {
+	Word string
+	// Start and End are byte offsets in the input
+	Start int
+	End   int
+	// CharStart and CharEnd are offsets in characters (runes) in the input
+	CharStart int
+	CharEnd   int
+}
 
-	return hashTags
+// Spans returns the position of each word of the hashtag in input, where the hashtag
+// starts at byte offset, such as after the @ of a handle.
+func (ht *HashTag) Spans(input string, offset int) []*Span {
+	ret := make([]*Span, len(ht.Words))
+	pos := offset
+	charPos := utf8.RuneCountInString(input[:clampOffset(offset, len(input))])
+	for i, w := range ht.Words {
+		if ht.Separators != nil {
+			pos += len(ht.Separators[i])
+			charPos += utf8.RuneCountInString(ht.Separators[i])
+		}
+		end := pos + len(w)
+		// the words are capitalized, but have the same length as the input they cover,
+		// so that the character offsets can be counted on the input itself
+		charEnd := charPos + utf8.RuneCountInString(input[clampOffset(pos, len(input)):clampOffset(end, len(input))])
+		ret[i] = &Span{
+			Word:      w,
+			Start:     pos,
+			End:       end,
+			CharStart: charPos,
+			CharEnd:   charEnd,
+		}
+		pos, charPos = end, charEnd
+	}
+	return ret
+}
+
+func clampOffset(pos int, length int) int {
+	if pos < 0 {
+		return 0
+	}
+	if pos > length {
+		return length
+	}
+	return pos
 }
@@ -1,12 +1,21 @@
 package pkg
 
 import (
+	"bufio"
 	ahocorasick "github.com/BobuSumisu/aho-corasick"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
 	"testing"
 )
 
+// expectedHashTag describes the tag string and word count a test expects
+type expectedHashTag struct {
+	Tag   string
+	Words int
+}
+
 func buildComplexTrie() *ahocorasick.Trie {
 	cleanerStrings := []string{
 		"slon",
@@ -57,8 +66,7 @@ func buildTrie(cleanerStrings []string) *ahocorasick.Trie {
 func TestSingleWordMatches(t *testing.T) {
 	trie := buildComplexTrie()
 	s := "cleaner"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 
 	allMatches := matches.AllMatches
@@ -78,106 +86,101 @@ func TestSingleWordMatches(t *testing.T) {
 func TestSingleLetterHashtag(t *testing.T) {
 	trie := buildTrie([]string{})
 	s := "a"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 	hashtags := matches.ComputeHashTags(0)
 	require.Equal(t, 1, len(hashtags))
-	assert.Equal(t, "A", hashtags[0].Tag)
+	assert.Equal(t, "A", hashtags[0].Tag())
 }
 
 func TestTwoLetterHashtag(t *testing.T) {
 	trie := buildTrie([]string{})
 	s := "ab"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 	var hashtags []*HashTag
 
 	hashtags = matches.ComputeHashTags(1)
 	require.Equal(t, 1, len(hashtags))
-	assert.Equal(t, "B", hashtags[0].Tag)
-	assert.Equal(t, 1, hashtags[0].Words)
+	assert.Equal(t, "B", hashtags[0].Tag())
+	assert.Equal(t, 1, len(hashtags[0].Words))
 
 	hashtags = matches.ComputeHashTags(0)
 	require.Equal(t, 1, len(hashtags))
-	assert.Equal(t, "AB", hashtags[0].Tag)
-	assert.Equal(t, 2, hashtags[0].Words)
+	assert.Equal(t, "AB", hashtags[0].Tag())
+	assert.Equal(t, 2, len(hashtags[0].Words))
 }
 
 func TestTwoLetterSingleWordHashtag(t *testing.T) {
 	trie := buildTrie([]string{"ab"})
 	s := "ab"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 	var hashtags []*HashTag
 
 	hashtags = matches.ComputeHashTags(1)
 	require.Equal(t, 1, len(hashtags))
-	assert.Equal(t, "B", hashtags[0].Tag)
-	assert.Equal(t, 1, hashtags[0].Words)
+	assert.Equal(t, "B", hashtags[0].Tag())
+	assert.Equal(t, 1, len(hashtags[0].Words))
 
 	hashtags = matches.ComputeHashTags(0)
 	require.Equal(t, 2, len(hashtags))
-	assert.Equal(t, "Ab", hashtags[0].Tag)
-	assert.Equal(t, 1, hashtags[0].Words)
-	assert.Equal(t, "AB", hashtags[1].Tag)
-	assert.Equal(t, 2, hashtags[1].Words)
+	assert.Equal(t, "Ab", hashtags[0].Tag())
+	assert.Equal(t, 1, len(hashtags[0].Words))
+	assert.Equal(t, "AB", hashtags[1].Tag())
+	assert.Equal(t, 2, len(hashtags[1].Words))
 }
 
 func TestTwoLetterTwoWordsHashtag(t *testing.T) {
 	trie := buildTrie([]string{"abc", "ab", "bc"})
 	s := "abc"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 	var hashtags []*HashTag
 
-	expected := []*HashTag{
-		&HashTag{Tag: "Bc", Words: 1},
-		&HashTag{Tag: "BC", Words: 2},
+	expected := []*expectedHashTag{
+		&expectedHashTag{Tag: "Bc", Words: 1},
+		&expectedHashTag{Tag: "BC", Words: 2},
 	}
 	hashtags = matches.ComputeHashTags(1)
 
 	require.Equal(t, 2, len(hashtags))
 	for i, h := range hashtags {
-		assert.Equal(t, expected[i].Tag, h.Tag)
-		assert.Equal(t, expected[i].Words, h.Words)
+		assert.Equal(t, expected[i].Tag, h.Tag())
+		assert.Equal(t, expected[i].Words, len(h.Words))
 	}
 
 	hashtags = matches.ComputeHashTags(0)
-	expected = []*HashTag{
-		&HashTag{Tag: "Abc", Words: 1},
-		&HashTag{Tag: "AbC", Words: 2},
-		&HashTag{Tag: "ABc", Words: 2},
-		&HashTag{Tag: "ABC", Words: 3},
+	expected = []*expectedHashTag{
+		&expectedHashTag{Tag: "Abc", Words: 1},
+		&expectedHashTag{Tag: "AbC", Words: 2},
+		&expectedHashTag{Tag: "ABc", Words: 2},
+		&expectedHashTag{Tag: "ABC", Words: 3},
 	}
 	require.Equal(t, len(expected), len(hashtags))
 	for i, h := range hashtags {
-		assert.Equal(t, expected[i].Tag, h.Tag)
-		assert.Equal(t, expected[i].Words, h.Words)
+		assert.Equal(t, expected[i].Tag, h.Tag())
+		assert.Equal(t, expected[i].Words, len(h.Words))
 	}
 }
 
 func TestTwoLetterTwoWordsHashtagIterative(t *testing.T) {
 	trie := buildTrie([]string{"abc", "ab", "bc"})
 	s := "abc"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 
 	hashtags := matches.ComputeHashTagsIterative(0)
-	expected := []*HashTag{
-		&HashTag{Tag: "Abc", Words: 1},
-		&HashTag{Tag: "AbC", Words: 2},
-		&HashTag{Tag: "ABc", Words: 2},
-		&HashTag{Tag: "ABC", Words: 3},
+	expected := []*expectedHashTag{
+		&expectedHashTag{Tag: "Abc", Words: 1},
+		&expectedHashTag{Tag: "AbC", Words: 2},
+		&expectedHashTag{Tag: "ABc", Words: 2},
+		&expectedHashTag{Tag: "ABC", Words: 3},
 	}
 	require.Equal(t, len(expected), len(hashtags))
 	for i, h := range hashtags {
-		assert.Equal(t, expected[i].Tag, h.Tag)
-		assert.Equal(t, expected[i].Words, h.Words)
+		assert.Equal(t, expected[i].Tag, h.Tag())
+		assert.Equal(t, expected[i].Words, len(h.Words))
 	}
 }
 
@@ -185,21 +188,20 @@ func TestSingleWordHashtags(t *testing.T) {
 	trie := buildTrie([]string{"cleaner", "clean", "leaner"})
 
 	s := "cleaner"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 
 	hashtags := matches.ComputeHashTags(0)
-	expected := []*HashTag{
-		&HashTag{Tag: "Cleaner", Words: 1},
-		&HashTag{Tag: "CLeaner", Words: 2},
-		&HashTag{Tag: "CleanER", Words: 3},
-		&HashTag{Tag: "CLEANER", Words: 7},
+	expected := []*expectedHashTag{
+		&expectedHashTag{Tag: "Cleaner", Words: 1},
+		&expectedHashTag{Tag: "CLeaner", Words: 2},
+		&expectedHashTag{Tag: "CleanER", Words: 3},
+		&expectedHashTag{Tag: "CLEANER", Words: 7},
 	}
 	require.Equal(t, len(expected), len(hashtags))
 	for i, h := range hashtags {
-		assert.Equal(t, expected[i].Tag, h.Tag)
-		assert.Equal(t, expected[i].Words, h.Words)
+		assert.Equal(t, expected[i].Tag, h.Tag())
+		assert.Equal(t, expected[i].Words, len(h.Words))
 	}
 }
 
@@ -207,20 +209,112 @@ func TestSingleWordHashtagsIterative(t *testing.T) {
 	trie := buildTrie([]string{"cleaner", "clean", "leaner"})
 
 	s := "cleaner"
-	trieMatches := trie.MatchString(s)
-	matches_ := ComputeMatches(s, trieMatches, nil)
+	matches_ := ComputeMatches(s, NewAhoCorasickMatcher(trie), nil)
 	matches := NewStringMatches(s, matches_)
 
 	hashtags := matches.ComputeHashTagsIterative(0)
-	expected := []*HashTag{
-		&HashTag{Tag: "Cleaner", Words: 1},
-		&HashTag{Tag: "CLeaner", Words: 2},
-		&HashTag{Tag: "CleanER", Words: 3},
-		&HashTag{Tag: "CLEANER", Words: 7},
+	expected := []*expectedHashTag{
+		&expectedHashTag{Tag: "Cleaner", Words: 1},
+		&expectedHashTag{Tag: "CLeaner", Words: 2},
+		&expectedHashTag{Tag: "CleanER", Words: 3},
+		&expectedHashTag{Tag: "CLEANER", Words: 7},
 	}
 	require.Equal(t, len(expected), len(hashtags))
 	for i, h := range hashtags {
-		assert.Equal(t, expected[i].Tag, h.Tag)
-		assert.Equal(t, expected[i].Words, h.Words)
+		assert.Equal(t, expected[i].Tag, h.Tag())
+		assert.Equal(t, expected[i].Words, len(h.Words))
+	}
+}
+
+func TestHashTagsDontShareWords(t *testing.T) {
+	trie := buildComplexTrie()
+
+	for _, s := range []string{"cleanerthisisascarpeslon", "thisisalongcleaner", "scarpethis"} {
+		matches := NewStringMatches(s, ComputeMatches(s, NewAhoCorasickMatcher(trie), nil))
+		hashtags := matches.SuggestHashtags()
+		require.NotEmpty(t, hashtags)
+		for _, h := range hashtags {
+			// every segmentation spells the input, with a score per word
+			assert.Equal(t, s, strings.ToLower(h.Tag()))
+			assert.Equal(t, len(h.Words), len(h.Scores))
+		}
+
+		top := matches.SuggestTopHashtags(3)
+		require.Len(t, top, 3)
+		for i, h := range top {
+			assert.Equal(t, hashtags[i].Tag(), h.Tag())
+			assert.Equal(t, hashtags[i].Scores, h.Scores)
+		}
+
+		// appending to a hashtag leaves the others alone
+		tag := top[1].Tag()
+		_ = top[0].AppendMatch("x", 1)
+		_ = top[0].AppendMatchWithSuffix("x", 1, top[2])
+		top[0].Words = append(top[0].Words, "X")
+		assert.Equal(t, tag, top[1].Tag())
 	}
 }
+
+func TestSpans(t *testing.T) {
+	ht := NewHashTag([]string{"Café", "Crème"}, []float64{1, 1})
+	spans := ht.Spans("cafécrème", 0)
+	require.Len(t, spans, 2)
+	assert.Equal(t, Span{Word: "Café", Start: 0, End: 5, CharStart: 0, CharEnd: 4}, *spans[0])
+	assert.Equal(t, Span{Word: "Crème", Start: 5, End: 11, CharStart: 4, CharEnd: 9}, *spans[1])
+
+	completer := NewCompleter(buildTrie([]string{"jane", "doe"}), nil)
+	input := "@janedoe_1990@mastodon.social"
+	suggestions := completer.Suggest(input, SuggestOptions{Mode: HandleMode, Count: 1})
+	require.NotEmpty(t, suggestions.HashTags)
+	for _, s := range suggestions.HashTags[0].Spans(input, suggestions.Offset) {
+		assert.Equal(t, s.Word, capitalize(input[s.Start:s.End]))
+	}
+}
+
+// benchmarkInputs returns the hashtags of the BOUN HashSet, the benchmarks are skipped
+// if it is not there.
+func benchmarkInputs(b *testing.B) []string {
+	file, err := os.Open("../test_data/hashset/boun-celebi-et-al.csv")
+	if err != nil {
+		b.Skip("no test_data/hashset")
+	}
+	defer file.Close()
+
+	ret := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		input := strings.ToLower(strings.SplitN(scanner.Text(), ",", 2)[0])
+		if input != "" && len(input) <= MaxInputLength {
+			ret = append(ret, input)
+		}
+	}
+	require.NoError(b, scanner.Err())
+	return ret
+}
+
+// BenchmarkSuggestHashtags runs the search over the HashSet inputs one after the other,
+// their lattices being computed beforehand.
+func BenchmarkSuggestHashtags(b *testing.B) {
+	matcher, err := BuildMatcher(DoubleArrayMatcherKind, benchmarkWords(b))
+	require.NoError(b, err)
+	inputs := benchmarkInputs(b)
+	allMatches := make([][][]*Match, len(inputs))
+	for i, input := range inputs {
+		allMatches[i] = ComputeMatches(input, matcher, nil)
+	}
+
+	b.Run("all", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			j := i % len(inputs)
+			NewStringMatches(inputs[j], allMatches[j]).SuggestHashtags()
+		}
+	})
+	b.Run("top-5", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			j := i % len(inputs)
+			NewStringMatches(inputs[j], allMatches[j]).SuggestTopHashtags(5)
+		}
+	})
+}
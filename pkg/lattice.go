@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"sort"
+)
+
+// LatticeEdge is a word of the input, going from the byte position where it starts
+// to the one where it ends.
+type LatticeEdge struct {
+	From  int
+	To    int
+	Word  string
+	Score float64
+}
+
+// Lattice is the word lattice of an input as a DAG, whose nodes are byte positions
+// in the input. It is pruned to the edges that lie on a path covering the whole input,
+// so that clients can re-rank or enumerate segmentations on their own.
+type Lattice struct {
+	Input string
+	// Nodes are the positions connected by edges, in increasing order
+	Nodes []int
+	Edges []*LatticeEdge
+}
+
+// Lattice returns the pruned word lattice of the matches, with their current scores,
+// which include the context boosts if ApplyContext was called.
+func (sm *StringMatches) Lattice() *Lattice {
+	n := len(sm.String)
+
+	// reachable[pos] is true if a path of words leads from the start to pos
+	reachable := make([]bool, n+1)
+	reachable[0] = true
+	for pos := 0; pos < n; pos++ {
+		if !reachable[pos] {
+			continue
+		}
+		for _, m := range sm.AllMatches[pos] {
+			reachable[pos+len(m.Match)] = true
+		}
+	}
+
+	// complete[pos] is true if a path of words leads from pos to the end
+	complete := make([]bool, n+1)
+	complete[n] = true
+	for pos := n - 1; pos >= 0; pos-- {
+		for _, m := range sm.AllMatches[pos] {
+			if complete[pos+len(m.Match)] {
+				complete[pos] = true
+				break
+			}
+		}
+	}
+
+	ret := &Lattice{
+		Input: sm.String,
+		Nodes: make([]int, 0),
+		Edges: make([]*LatticeEdge, 0),
+	}
+	if n == 0 || !complete[0] {
+		return ret
+	}
+
+	nodes := make(map[int]bool)
+	for pos := 0; pos < n; pos++ {
+		if !reachable[pos] {
+			continue
+		}
+		for _, m := range sm.AllMatches[pos] {
+			end := pos + len(m.Match)
+			if !complete[end] {
+				continue
+			}
+			ret.Edges = append(ret.Edges, &LatticeEdge{
+				From:  pos,
+				To:    end,
+				Word:  m.Match,
+				Score: m.Score,
+			})
+			nodes[pos], nodes[end] = true, true
+		}
+	}
+	for pos := range nodes {
+		ret.Nodes = append(ret.Nodes, pos)
+	}
+	sort.Ints(ret.Nodes)
+
+	return ret
+}
+
+// StringMatches turns the lattice back into matches, so that its segmentations can
+// be searched like those of a dictionary lookup.
+func (l *Lattice) StringMatches() *StringMatches {
+	matches := make([][]*Match, len(l.Input))
+	for _, e := range l.Edges {
+		if e.From < 0 || e.To > len(l.Input) || e.From >= e.To {
+			continue
+		}
+		matches[e.From] = append(matches[e.From], &Match{
+			Match: e.Word,
+			Pos:   e.From,
+			Score: e.Score,
+		})
+	}
+	for _, ms_ := range matches {
+		sort.Slice(ms_, func(i, j int) bool {
+			return ms_[i].Score > ms_[j].Score
+		})
+	}
+	return NewStringMatches(l.Input, matches)
+}
+
+// Paths enumerates the segmentations of the lattice, ranked the same way as the
+// suggestions of the server. A count of 0 means no limit.
+func (l *Lattice) Paths(count int) []*HashTag {
+	return l.StringMatches().SuggestTopHashtags(count)
+}
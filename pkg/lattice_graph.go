@@ -0,0 +1,152 @@
+package pkg
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// LatticeGraph is a lattice along with the segmentations to highlight when rendering it.
+type LatticeGraph struct {
+	Lattice *Lattice
+	// Paths are the best segmentations, best first. The first one is highlighted
+	// as the best path, the others as the top-K paths.
+	Paths []*HashTag
+}
+
+func NewLatticeGraph(lattice *Lattice, paths []*HashTag) *LatticeGraph {
+	return &LatticeGraph{
+		Lattice: lattice,
+		Paths:   paths,
+	}
+}
+
+type latticeSpan struct {
+	from, to int
+}
+
+// pathRanks returns, for each edge lying on one of the paths, the rank of the best path
+// it lies on.
+func (g *LatticeGraph) pathRanks() map[latticeSpan]int {
+	ret := make(map[latticeSpan]int)
+	for rank := len(g.Paths) - 1; rank >= 0; rank-- {
+		pos := 0
+		for _, w := range g.Paths[rank].Words {
+			ret[latticeSpan{pos, pos + len(w)}] = rank
+			pos += len(w)
+		}
+	}
+	return ret
+}
+
+func (g *LatticeGraph) edgeRank(ranks map[latticeSpan]int, e *LatticeEdge) int {
+	if rank, ok := ranks[latticeSpan{e.From, e.To}]; ok {
+		return rank
+	}
+	return -1
+}
+
+func escapeDOT(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// DOT renders the lattice as a Graphviz digraph, with one node per position and
+// one edge per word, labelled with its score.
+func (g *LatticeGraph) DOT() string {
+	ranks := g.pathRanks()
+
+	var sb strings.Builder
+	sb.WriteString("digraph lattice {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString(fmt.Sprintf("  label=\"%s\";\n", escapeDOT(g.Lattice.Input)))
+	sb.WriteString("  node [shape=circle];\n")
+	for _, n := range g.Lattice.Nodes {
+		sb.WriteString(fmt.Sprintf("  n%d [label=\"%d\"];\n", n, n))
+	}
+	for _, e := range g.Lattice.Edges {
+		attrs := fmt.Sprintf("label=\"%s (%.1f)\"", escapeDOT(e.Word), e.Score)
+		switch rank := g.edgeRank(ranks, e); {
+		case rank == 0:
+			attrs += ", color=red, fontcolor=red, penwidth=3"
+		case rank > 0:
+			attrs += ", color=blue, fontcolor=blue, penwidth=2"
+		default:
+			attrs += ", color=gray"
+		}
+		sb.WriteString(fmt.Sprintf("  n%d -> n%d [%s];\n", e.From, e.To, attrs))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+const (
+	svgCharWidth = 40
+	svgMargin    = 30
+	svgFontSize  = 12
+)
+
+// SVG renders the lattice with the characters of the input laid out on a line, and
+// each word as an arc above the characters it covers, labelled with its score.
+func (g *LatticeGraph) SVG() string {
+	ranks := g.pathRanks()
+	n := len(g.Lattice.Input)
+
+	maxSpan := 1
+	for _, e := range g.Lattice.Edges {
+		if e.To-e.From > maxSpan {
+			maxSpan = e.To - e.From
+		}
+	}
+	baseline := svgMargin + maxSpan*svgCharWidth/2 + svgFontSize
+	width := 2*svgMargin + n*svgCharWidth
+	height := baseline + 2*svgFontSize + svgMargin
+	x := func(pos int) int {
+		return svgMargin + pos*svgCharWidth
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%d">`+"\n",
+		width, height, width, height, svgFontSize))
+	sb.WriteString(fmt.Sprintf(`<title>%s</title>`+"\n", html.EscapeString(g.Lattice.Input)))
+
+	for i, r := range g.Lattice.Input {
+		sb.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+			x(i)+svgCharWidth/2, baseline+2*svgFontSize, html.EscapeString(string(r))))
+	}
+	for _, p := range g.Lattice.Nodes {
+		sb.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="3"/>`+"\n", x(p), baseline))
+	}
+
+	// draw the other edges first, so that the highlighted paths are drawn on top
+	for _, pass := range []func(rank int) bool{
+		func(rank int) bool { return rank < 0 },
+		func(rank int) bool { return rank > 0 },
+		func(rank int) bool { return rank == 0 },
+	} {
+		for _, e := range g.Lattice.Edges {
+			rank := g.edgeRank(ranks, e)
+			if !pass(rank) {
+				continue
+			}
+			color, strokeWidth := "#999999", 1
+			switch {
+			case rank == 0:
+				color, strokeWidth = "#d62728", 3
+			case rank > 0:
+				color, strokeWidth = "#1f77b4", 2
+			}
+
+			x1, x2 := x(e.From), x(e.To)
+			h := (x2 - x1) / 2
+			sb.WriteString(fmt.Sprintf(
+				`<path d="M %d %d C %d %d, %d %d, %d %d" fill="none" stroke="%s" stroke-width="%d"/>`+"\n",
+				x1, baseline, x1, baseline-h, x2, baseline-h, x2, baseline, color, strokeWidth))
+			sb.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" fill="%s">%s (%.1f)</text>`+"\n",
+				(x1+x2)/2, baseline-h*3/4-2, color, html.EscapeString(e.Word), e.Score))
+		}
+	}
+
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"fmt"
+	ahocorasick "github.com/BobuSumisu/aho-corasick"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// buildWordsTrie builds a trie without the single letters added by buildTrie
+func buildWordsTrie(words []string) *ahocorasick.Trie {
+	builder := ahocorasick.NewTrieBuilder()
+	builder.AddStrings(words)
+	return builder.Build()
+}
+
+func TestLattice(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"who", "whore", "represents", "presents", "re"}), nil)
+	sm := completer.ComputeStringMatches("whorepresents")
+	lattice := sm.Lattice()
+
+	assert.Equal(t, []int{0, 3, 5, 13}, lattice.Nodes)
+	words := make([]string, len(lattice.Edges))
+	for i, e := range lattice.Edges {
+		words[i] = e.Word
+		assert.Equal(t, e.Word, lattice.Input[e.From:e.To])
+	}
+	assert.ElementsMatch(t, []string{"who", "whore", "represents", "re", "presents"}, words)
+
+	paths := lattice.Paths(0)
+	expected := sm.SuggestHashtags()
+	require.Len(t, paths, len(expected))
+	for i := range paths {
+		assert.Equal(t, expected[i].Tag(), paths[i].Tag())
+	}
+	assert.Len(t, lattice.Paths(1), 1)
+}
+
+func TestLatticeDeadEnds(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"ab", "a", "bc"}), nil)
+	lattice := completer.ComputeStringMatches("abc").Lattice()
+	require.Len(t, lattice.Edges, 2)
+	assert.Equal(t, "a", lattice.Edges[0].Word)
+	assert.Equal(t, "bc", lattice.Edges[1].Word)
+
+	lattice = completer.ComputeStringMatches("abz").Lattice()
+	assert.Empty(t, lattice.Edges)
+	assert.Empty(t, lattice.Paths(0))
+}
+
+func TestLatticeGraph(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"who", "whore", "represents", "presents", "re"}), nil)
+	sm := completer.ComputeStringMatches("whorepresents")
+	lattice := sm.Lattice()
+	paths := lattice.Paths(2)
+	graph := NewLatticeGraph(lattice, paths)
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, "digraph lattice {")
+	best := paths[0].Words
+	first := strings.ToLower(best[0])
+	assert.Contains(t, dot,
+		fmt.Sprintf("n0 -> n%d [label=\"%s (%.1f)\", color=red", len(first), first, WordScore(first, nil)))
+	assert.Equal(t, len(lattice.Edges), strings.Count(dot, "->"))
+
+	svg := graph.SVG()
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Equal(t, len(lattice.Edges), strings.Count(svg, "<path "))
+	assert.Equal(t, len(best), strings.Count(svg, `stroke="#d62728"`))
+}
@@ -0,0 +1,245 @@
+package pkg
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lexicon is the dictionary matcher and the word frequencies loaded from files.
+type Lexicon struct {
+	Matcher   WordMatcher
+	Frequency map[string]int
+	// Casing maps lowercase words to the casing they are always written in, see Style
+	Casing map[string]string
+	// Version identifies the content of the files, it only changes when they do
+	Version string
+	// Words is the number of words of the dictionary files
+	Words int
+}
+
+// LoadLexicon builds the lexicon of the dictionary files and the frequency file, and
+// checks that neither is empty. The dictionary words are matched with a matcher of kind.
+func LoadLexicon(dictPaths []string, frequencyPath string, kind MatcherKind) (*Lexicon, error) {
+	hash := sha256.New()
+	casing := loadCasingList(casingFile)
+	dict := make([]string, 0)
+	for _, path := range dictPaths {
+		_, err := hashLines(path, hash, func(line string) {
+			dict = append(dict, strings.ToLower(line))
+			if isCanonicalCasing(line) {
+				casing[strings.ToLower(line)] = line
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err := hashLines(frequencyPath, hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(dict) == 0 {
+		return nil, errors.New("the dictionaries are empty")
+	}
+
+	matcher, err := BuildMatcher(kind, dict)
+	if err != nil {
+		return nil, err
+	}
+	frequency, err := LoadWordFrequencies(frequencyPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load frequencies %s: %w", frequencyPath, err)
+	}
+	if len(frequency) == 0 {
+		return nil, fmt.Errorf("no word frequencies in %s", frequencyPath)
+	}
+
+	return &Lexicon{
+		Matcher:   matcher,
+		Frequency: frequency,
+		Casing:    casing,
+		Version:   hex.EncodeToString(hash.Sum(nil))[:12],
+		Words:     len(dict),
+	}, nil
+}
+
+// isCanonicalCasing reports whether a dictionary word has a casing of its own, such as
+// iPhone or NASA, rather than being lowercase or a capitalized name.
+func isCanonicalCasing(word string) bool {
+	lower := strings.ToLower(word)
+	return len(word) > 1 && word != lower && word != capitalize(lower)
+}
+
+// hashLines writes the path and the lines of the file to hash, and returns the number
+// of non-empty lines. Each line is also passed to fn, if it is not nil.
+func hashLines(path string, hash io.Writer, fn func(line string)) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	_, _ = hash.Write([]byte(path + "\n"))
+	n := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		_, _ = hash.Write([]byte(line + "\n"))
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		n++
+		if fn != nil {
+			fn(line)
+		}
+	}
+	return n, scanner.Err()
+}
+
+// LexiconReloader reloads the lexicon of a dictionary from its files, keeping the
+// current one if they can't be loaded.
+type LexiconReloader struct {
+	Dictionary    *Dictionary
+	DictPaths     []string
+	FrequencyPath string
+	// SnapshotPath is the lexicon snapshot to reload instead of the files, if not empty.
+	// Snapshots are verified against their checksum before being swapped in.
+	SnapshotPath string
+	// Matcher is the kind of matcher built out of the files, snapshots record their own
+	Matcher MatcherKind
+
+	// mu serializes the reloads
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+}
+
+func NewLexiconReloader(dictionary *Dictionary, dictPaths []string, frequencyPath string) *LexiconReloader {
+	r := &LexiconReloader{
+		Dictionary:    dictionary,
+		DictPaths:     dictPaths,
+		FrequencyPath: frequencyPath,
+	}
+	r.modTimes = r.statFiles()
+	return r
+}
+
+// NewSnapshotReloader reloads the lexicon of dictionary from the snapshot at path,
+// see WriteLexiconSnapshot.
+func NewSnapshotReloader(dictionary *Dictionary, path string) *LexiconReloader {
+	r := &LexiconReloader{
+		Dictionary:   dictionary,
+		SnapshotPath: path,
+	}
+	r.modTimes = r.statFiles()
+	return r
+}
+
+func (r *LexiconReloader) load() (*Lexicon, error) {
+	if r.SnapshotPath != "" {
+		return OpenLexiconSnapshot(r.SnapshotPath, true)
+	}
+	return LoadLexicon(r.DictPaths, r.FrequencyPath, r.Matcher)
+}
+
+func (r *LexiconReloader) paths() []string {
+	if r.SnapshotPath != "" {
+		return []string{r.SnapshotPath}
+	}
+	return append(append([]string{}, r.DictPaths...), r.FrequencyPath)
+}
+
+func (r *LexiconReloader) statFiles() map[string]time.Time {
+	ret := make(map[string]time.Time)
+	for _, path := range r.paths() {
+		info, err := os.Stat(path)
+		if err == nil {
+			ret[path] = info.ModTime()
+		}
+	}
+	return ret
+}
+
+// Reload loads the files and swaps the new lexicon into the dictionary. If loading
+// fails, the dictionary keeps its current lexicon and the error is returned.
+func (r *LexiconReloader) Reload() (*Lexicon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reload()
+}
+
+func (r *LexiconReloader) reload() (*Lexicon, error) {
+	start := time.Now()
+	previous := r.Dictionary.Version()
+	// the files are stat'ed first so that a change made while loading triggers another reload
+	modTimes := r.statFiles()
+
+	lexicon, err := r.load()
+	r.modTimes = modTimes
+	if err != nil {
+		log.Error().Err(err).Str("version", previous).Msg("Could not reload the lexicon, keeping the current one")
+		return nil, err
+	}
+	if lexicon.Version == previous {
+		log.Info().Str("version", lexicon.Version).Msg("Lexicon unchanged")
+		return lexicon, nil
+	}
+
+	r.Dictionary.Reload(lexicon)
+	log.Info().
+		Str("version", lexicon.Version).
+		Str("previous", previous).
+		Int("words", lexicon.Words).
+		Int("frequencies", len(lexicon.Frequency)).
+		Dur("duration", time.Since(start)).
+		Msg("Reloaded the lexicon")
+	return lexicon, nil
+}
+
+// Changed reports whether a file was modified since it was last loaded.
+func (r *LexiconReloader) Changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changed()
+}
+
+func (r *LexiconReloader) changed() bool {
+	modTimes := r.statFiles()
+	if len(modTimes) != len(r.modTimes) {
+		return true
+	}
+	for path, t := range modTimes {
+		if !t.Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch checks the files every interval and reloads them when they change, until done is closed.
+func (r *LexiconReloader) Watch(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			if r.changed() {
+				// errors are logged, and the files are retried once they change again
+				_, _ = r.reload()
+			}
+			r.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeLexiconFiles(t *testing.T, dir string, words string, frequencies string) ([]string, string) {
+	dictPath := filepath.Join(dir, "words")
+	frequencyPath := filepath.Join(dir, "freq")
+	require.NoError(t, os.WriteFile(dictPath, []byte(words), 0644))
+	require.NoError(t, os.WriteFile(frequencyPath, []byte("Word\tPoS\tFreq\n"+frequencies), 0644))
+	return []string{dictPath}, frequencyPath
+}
+
+func TestLoadLexicon(t *testing.T) {
+	dir := t.TempDir()
+	dicts, frequencyPath := writeLexiconFiles(t, dir, "who\nRepresents\n\n", "who\tPron\t2000\n")
+
+	lexicon, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	assert.Equal(t, 2, lexicon.Words)
+	assert.Equal(t, map[string]int{"who": 2000}, lexicon.Frequency)
+	assert.Len(t, lexicon.Version, 12)
+
+	same, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	assert.Equal(t, lexicon.Version, same.Version)
+
+	writeLexiconFiles(t, dir, "who\nrepresents\npresents\n", "who\tPron\t2000\n")
+	changed, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	assert.NotEqual(t, lexicon.Version, changed.Version)
+
+	writeLexiconFiles(t, dir, "\n", "who\tPron\t2000\n")
+	_, err = LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	assert.Error(t, err)
+
+	writeLexiconFiles(t, dir, "who\n", "")
+	_, err = LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	assert.Error(t, err)
+}
+
+func TestLexiconReloader(t *testing.T) {
+	dir := t.TempDir()
+	dicts, frequencyPath := writeLexiconFiles(t, dir, "pen\nisland\n", "pen\tNoun\t100\n")
+	lexicon, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+
+	completer := NewDictionaryCompleter(NewLexiconDictionary(lexicon))
+	require.NoError(t, completer.Dictionary.AddWords(0, "mastodon"))
+	reloader := NewLexiconReloader(completer.Dictionary, dicts, frequencyPath)
+	assert.False(t, reloader.Changed())
+
+	writeLexiconFiles(t, dir, "pen\nisland\npenis\nland\n", "pen\tNoun\t100\n")
+	// make sure the modification time changes even on coarse filesystems
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(dicts[0], later, later))
+	assert.True(t, reloader.Changed())
+
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloader.Changed())
+	assert.NotEqual(t, lexicon.Version, reloaded.Version)
+	suggestions := completer.Suggest("penisland", SuggestOptions{})
+	assert.Equal(t, reloaded.Version, suggestions.LexiconVersion)
+	assert.Len(t, suggestions.HashTags, 2)
+	// the runtime changes survive the reload
+	assert.Equal(t, "Mastodon", completer.Suggest("mastodon", SuggestOptions{}).HashTags[0].Tag())
+
+	// a broken file keeps the current lexicon
+	require.NoError(t, os.Remove(frequencyPath))
+	_, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, reloaded.Version, completer.Dictionary.Version())
+	assert.Len(t, completer.Suggest("penisland", SuggestOptions{}).HashTags, 2)
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"fmt"
+	ahocorasick "github.com/BobuSumisu/aho-corasick"
+	"strings"
+)
+
+// WordMatcher finds the dictionary words occurring in an input, see ComputeMatches.
+type WordMatcher interface {
+	// MatchWords calls fn with the byte position and the text of every dictionary word
+	// found in input, overlapping words included. The words starting at the same
+	// position are reported shortest first, the order is otherwise up to the matcher.
+	MatchWords(input string, fn func(pos int, word string))
+}
+
+// MatcherKind selects the data structure the dictionary words are matched with.
+type MatcherKind string
+
+const (
+	// AhoCorasickMatcherKind is the aho-corasick automaton, which keeps a table of 256
+	// transitions per node and so takes GBs for a full dictionary
+	AhoCorasickMatcherKind MatcherKind = "aho-corasick"
+	// DoubleArrayMatcherKind is a double-array trie, a few MBs for the same dictionary.
+	// It walks the trie from every start position, which is cheap for inputs of hashtag length.
+	DoubleArrayMatcherKind MatcherKind = "double-array"
+)
+
+func ParseMatcherKind(s string) (MatcherKind, error) {
+	switch MatcherKind(strings.ToLower(s)) {
+	case "", AhoCorasickMatcherKind:
+		return AhoCorasickMatcherKind, nil
+	case DoubleArrayMatcherKind:
+		return DoubleArrayMatcherKind, nil
+	default:
+		return "", fmt.Errorf("unknown matcher %s", s)
+	}
+}
+
+// BuildMatcher builds a matcher of the given kind out of lowercase words.
+func BuildMatcher(kind MatcherKind, words []string) (WordMatcher, error) {
+	switch kind {
+	case "", AhoCorasickMatcherKind:
+		builder := ahocorasick.NewTrieBuilder()
+		builder.AddStrings(words)
+		return NewAhoCorasickMatcher(builder.Build()), nil
+	case DoubleArrayMatcherKind:
+		return BuildDoubleArrayTrie(words), nil
+	default:
+		return nil, fmt.Errorf("unknown matcher %s", kind)
+	}
+}
+
+// AhoCorasickMatcher matches the words of an aho-corasick trie.
+type AhoCorasickMatcher struct {
+	Trie *ahocorasick.Trie
+}
+
+func NewAhoCorasickMatcher(trie *ahocorasick.Trie) *AhoCorasickMatcher {
+	return &AhoCorasickMatcher{Trie: trie}
+}
+
+func (m *AhoCorasickMatcher) MatchWords(input string, fn func(pos int, word string)) {
+	for _, match := range m.Trie.MatchString(input) {
+		fn(int(match.Pos()), match.MatchString())
+	}
+}
@@ -0,0 +1,152 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type wordMatch struct {
+	pos  int
+	word string
+}
+
+func collectMatches(matcher WordMatcher, input string) []wordMatch {
+	ret := []wordMatch{}
+	matcher.MatchWords(input, func(pos int, word string) {
+		ret = append(ret, wordMatch{pos, word})
+	})
+	return ret
+}
+
+func TestDoubleArrayTrie(t *testing.T) {
+	trie := BuildDoubleArrayTrie([]string{"pen", "penis", "island", "is", "land", "pen", "", "a"})
+	for _, w := range []string{"pen", "penis", "island", "is", "land", "a"} {
+		assert.True(t, trie.Contains(w), w)
+	}
+	for _, w := range []string{"", "p", "pe", "isl", "lands", "b"} {
+		assert.False(t, trie.Contains(w), w)
+	}
+
+	assert.Equal(t, []wordMatch{
+		{0, "pen"}, {0, "penis"}, {3, "is"}, {3, "island"}, {5, "land"}, {6, "a"},
+	}, collectMatches(trie, "penisland"))
+	assert.Empty(t, collectMatches(trie, "xyz"))
+	assert.Empty(t, collectMatches(BuildDoubleArrayTrie(nil), "penisland"))
+}
+
+func TestDoubleArrayTrieMatchesAhoCorasick(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	randomString := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "abcde\xc3\xa9"[r.Intn(7)]
+		}
+		return string(b)
+	}
+	words := make([]string, 2000)
+	for i := range words {
+		words[i] = randomString(1 + r.Intn(8))
+	}
+
+	aho, err := BuildMatcher(AhoCorasickMatcherKind, words)
+	require.NoError(t, err)
+	dat, err := BuildMatcher(DoubleArrayMatcherKind, words)
+	require.NoError(t, err)
+	// aho-corasick reports the words by end position, double-array tries by start position
+	sorted := func(matches []wordMatch) []wordMatch {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].pos < matches[j].pos
+		})
+		return matches
+	}
+	for i := 0; i < 200; i++ {
+		input := randomString(r.Intn(30))
+		assert.Equal(t, sorted(collectMatches(aho, input)), sorted(collectMatches(dat, input)), input)
+	}
+}
+
+func TestParseMatcherKind(t *testing.T) {
+	kind, err := ParseMatcherKind("")
+	require.NoError(t, err)
+	assert.Equal(t, AhoCorasickMatcherKind, kind)
+	kind, err = ParseMatcherKind("Double-Array")
+	require.NoError(t, err)
+	assert.Equal(t, DoubleArrayMatcherKind, kind)
+	_, err = ParseMatcherKind("fst")
+	assert.Error(t, err)
+}
+
+// benchmarkWords returns the words of the default dictionary, the benchmarks are skipped
+// if it is not there.
+func benchmarkWords(b *testing.B) []string {
+	file, err := os.Open("../test_data/words")
+	if err != nil {
+		b.Skip("no test_data/words")
+	}
+	defer file.Close()
+
+	ret := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			ret = append(ret, strings.ToLower(line))
+		}
+	}
+	require.NoError(b, scanner.Err())
+	return ret
+}
+
+func heapAlloc() uint64 {
+	runtime.GC()
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// BenchmarkBuildMatcher reports the build time and the heap size of each matcher,
+// run it with -benchtime=1x to only build them once.
+func BenchmarkBuildMatcher(b *testing.B) {
+	words := benchmarkWords(b)
+	for _, kind := range []MatcherKind{AhoCorasickMatcherKind, DoubleArrayMatcherKind} {
+		b.Run(string(kind), func(b *testing.B) {
+			var matcher WordMatcher
+			b.StopTimer()
+			for i := 0; i < b.N; i++ {
+				matcher = nil
+				before := heapAlloc()
+				b.StartTimer()
+				var err error
+				matcher, err = BuildMatcher(kind, words)
+				require.NoError(b, err)
+				b.StopTimer()
+				b.ReportMetric(float64(heapAlloc()-before)/(1<<20), "MB")
+			}
+			runtime.KeepAlive(matcher)
+		})
+	}
+}
+
+func BenchmarkMatchWords(b *testing.B) {
+	words := benchmarkWords(b)
+	inputs := []string{"whorepresents", "penisland", "expertsexchange", "thisisaveryveryverylonghashtagforbenchmarks"}
+	for _, kind := range []MatcherKind{AhoCorasickMatcherKind, DoubleArrayMatcherKind} {
+		matcher, err := BuildMatcher(kind, words)
+		require.NoError(b, err)
+		for _, input := range inputs {
+			b.Run(fmt.Sprintf("%s/%s", kind, input), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					matcher.MatchWords(input, func(pos int, word string) {})
+				}
+			})
+		}
+		runtime.KeepAlive(matcher)
+	}
+}
@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Piece is a part of an input that is segmented on its own, such as the letters
+// between the underscores of a handle, or a label of a domain name.
+type Piece struct {
+	Text string
+	// Pos is the byte offset of Text in the original input
+	Pos int
+	// Separator is the text between the previous piece and this one
+	Separator string
+	// Fixed pieces are kept as a single word as they are, instead of being
+	// segmented through the lattice
+	Fixed bool
+}
+
+// SplitPieces splits s into runs of letters, which are segmented through the lattice,
+// and runs of digits, which are kept as words of their own. Every other character
+// is a separator. offset is the position of s in the original input.
+func SplitPieces(s string, offset int) ([]*Piece, string) {
+	pieces := make([]*Piece, 0)
+	separator := ""
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			separator += string(r)
+			i += size
+			continue
+		}
+
+		isDigit := unicode.IsDigit(r)
+		end := i + strings.IndexFunc(s[i:], func(r rune) bool {
+			return unicode.IsDigit(r) != isDigit || (!unicode.IsLetter(r) && !unicode.IsDigit(r))
+		})
+		if end < i {
+			end = len(s)
+		}
+
+		pieces = append(pieces, &Piece{
+			Text:      s[i:end],
+			Pos:       offset + i,
+			Separator: separator,
+			Fixed:     isDigit,
+		})
+		separator = ""
+		i = end
+	}
+
+	return pieces, separator
+}
+
+// defaultPiecesBeamWidth bounds the number of combinations kept by SegmentPieces
+// when no count is given, as their number grows exponentially with the number of pieces.
+const defaultPiecesBeamWidth = 20
+
+// SegmentPieces segments each piece on its own and combines the best segmentations
+// of the pieces into at most options.Count hashtags, keeping the separators between the pieces.
+// The context, strategy and constraints of options apply to each piece.
+// The matches and context adjustments of all pieces are returned with positions
+// relative to the original input.
+func (c *Completer) SegmentPieces(
+	pieces []*Piece,
+	trailer string,
+	options SuggestOptions,
+) ([]*HashTag, []*Match, []*ContextAdjustment) {
+	allMatches := make([]*Match, 0)
+	adjustments := make([]*ContextAdjustment, 0)
+	if len(pieces) == 0 {
+		return []*HashTag{}, allMatches, adjustments
+	}
+
+	count := options.Count
+	if count <= 0 {
+		count = defaultPiecesBeamWidth
+	}
+	maxWords := 0
+	if options.Constraints != nil {
+		maxWords = options.Constraints.MaxWords
+	}
+
+	candidates := []*HashTag{{Words: []string{}, Scores: []float64{}, Separators: []string{}}}
+
+	for _, p := range pieces {
+		var segmentations []*HashTag
+		if !p.Fixed && len(p.Text) <= MaxInputLength {
+			matches := c.computeMatches(strings.ToLower(p.Text), options)
+			matches.Constrain(options.Constraints, c.Frequency())
+			as := matches.ApplyContext(options.Context)
+			as = append(as, matches.ApplyFeedback(c.Feedback)...)
+			segmentations = matches.SuggestHashtags()
+			as = append(as, options.Context.AdjustHashTags(segmentations)...)
+
+			for _, ms_ := range matches.AllMatches {
+				for _, m := range ms_ {
+					allMatches = append(allMatches, &Match{Match: m.Match, Pos: m.Pos + p.Pos, Score: m.Score, Source: m.Source})
+				}
+			}
+			for _, a := range as {
+				a.Pos += p.Pos
+				adjustments = append(adjustments, a)
+			}
+		}
+		if len(segmentations) == 0 {
+			// digits, or letters that can't be segmented with the dictionary
+			word := p.Text
+			if !p.Fixed {
+				word = capitalize(strings.ToLower(word))
+			}
+			segmentation := NewHashTag([]string{word}, []float64{options.Strategy.WordScore(strings.ToLower(p.Text), c.Frequency())})
+			segmentation.Strategy = options.Strategy
+			segmentations = []*HashTag{segmentation}
+		}
+		if len(segmentations) > count {
+			segmentations = segmentations[:count]
+		}
+
+		next := make([]*HashTag, 0, len(candidates)*len(segmentations))
+		for _, candidate := range candidates {
+			for _, option := range segmentations {
+				if maxWords > 0 && len(candidate.Words)+len(option.Words) > maxWords {
+					continue
+				}
+				separators := make([]string, len(option.Words))
+				separators[0] = p.Separator
+				next = append(next, &HashTag{
+					Words:      append(candidate.Words[:len(candidate.Words):len(candidate.Words)], option.Words...),
+					Scores:     append(candidate.Scores[:len(candidate.Scores):len(candidate.Scores)], option.Scores...),
+					Separators: append(candidate.Separators[:len(candidate.Separators):len(candidate.Separators)], separators...),
+					Strategy:   options.Strategy,
+				})
+			}
+		}
+		SortHashTags(next)
+		if len(next) > count {
+			next = next[:count]
+		}
+		candidates = next
+	}
+
+	for _, candidate := range candidates {
+		candidate.Separators = append(candidate.Separators, trailer)
+	}
+
+	return candidates, allMatches, adjustments
+}
@@ -0,0 +1,277 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefineSession keeps the match lattice of an input around while a user locks in
+// the words of their hashtag one by one. Each refinement step only re-runs the
+// segmentation on the remaining suffix, and reuses the StringMatches computed
+// when the session was started.
+type RefineSession struct {
+	Token   string
+	Matches *StringMatches
+	// Words are the (capitalized) words locked in so far
+	Words  []string
+	Scores []float64
+	// Pos is the byte position at which the remaining suffix starts
+	Pos int
+
+	mu       sync.Mutex
+	suffixes map[int][]*HashTag
+	expires  time.Time
+}
+
+// RefineOption is a candidate for the next word, along with the best hashtag
+// that starts with it.
+type RefineOption struct {
+	Word  string
+	Score float64
+	Best  *HashTag
+}
+
+// RefineState is a copy of the progress of a RefineSession.
+type RefineState struct {
+	Words     []string
+	Pos       int
+	Remaining string
+	Done      bool
+}
+
+// State returns the words locked in so far and the position of the remaining suffix.
+// Unlike the fields of the session, it can be read while the session is being refined.
+func (s *RefineSession) State() RefineState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return RefineState{
+		Words:     append([]string{}, s.Words...),
+		Pos:       s.Pos,
+		Remaining: s.Remaining(),
+		Done:      s.Done(),
+	}
+}
+
+// Remaining returns the part of the input that hasn't been locked in yet.
+func (s *RefineSession) Remaining() string {
+	return s.Matches.String[s.Pos:]
+}
+
+// Done returns true once the whole input has been covered by locked words.
+func (s *RefineSession) Done() bool {
+	return s.Pos >= len(s.Matches.String)
+}
+
+// ChooseWord locks in word as the next word of the hashtag.
+func (s *RefineSession) ChooseWord(word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	word = strings.ToLower(word)
+	if word == "" || !strings.HasPrefix(strings.ToLower(s.Remaining()), word) {
+		return fmt.Errorf("word %s does not start the remaining input %s", word, s.Remaining())
+	}
+	return s.chooseBoundary(s.Pos + len(word))
+}
+
+// ChooseBoundary locks in the text between the current position and boundary
+// as the next word. The word doesn't need to be in the dictionary.
+func (s *RefineSession) ChooseBoundary(boundary int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.chooseBoundary(boundary)
+}
+
+func (s *RefineSession) chooseBoundary(boundary int) error {
+	if boundary <= s.Pos || boundary > len(s.Matches.String) {
+		return fmt.Errorf("boundary %d is outside of the remaining input (%d-%d)",
+			boundary, s.Pos, len(s.Matches.String))
+	}
+
+	word := s.Matches.String[s.Pos:boundary]
+	score := 0.0
+	for _, m := range s.Matches.AllMatches[s.Pos] {
+		if len(m.Match) == len(word) {
+			score = m.Score
+			break
+		}
+	}
+
+	s.Words = append(s.Words[:len(s.Words):len(s.Words)], capitalize(word))
+	s.Scores = append(s.Scores[:len(s.Scores):len(s.Scores)], score)
+	s.Pos = boundary
+
+	return nil
+}
+
+// suffixHashTags returns the hashtags covering the input from pos, computing
+// them only once per session.
+func (s *RefineSession) suffixHashTags(pos int) []*HashTag {
+	if ret, ok := s.suffixes[pos]; ok {
+		return ret
+	}
+	ret := s.Matches.ComputeHashTagsIterativeFrom(pos, 0)
+	s.suffixes[pos] = ret
+	return ret
+}
+
+// HashTags returns the full hashtags made of the locked words followed by the best
+// segmentations of the remaining suffix, sorted by score.
+func (s *RefineSession) HashTags(count int) []*HashTag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hashTags(count)
+}
+
+func (s *RefineSession) hashTags(count int) []*HashTag {
+	prefix := NewHashTag(s.Words, s.Scores)
+	if s.Done() {
+		return []*HashTag{prefix}
+	}
+
+	ret := make([]*HashTag, 0)
+	for _, suffix := range s.suffixHashTags(s.Pos) {
+		ret = append(ret, NewHashTag(
+			append(s.Words[:len(s.Words):len(s.Words)], suffix.Words...),
+			append(s.Scores[:len(s.Scores):len(s.Scores)], suffix.Scores...),
+		))
+	}
+	SortHashTags(ret)
+
+	if count > 0 && len(ret) > count {
+		ret = ret[:count]
+	}
+	return ret
+}
+
+// NextWords returns the candidates for the next word, ordered by the score of the best
+// hashtag they lead to.
+func (s *RefineSession) NextWords(count int) []*RefineOption {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]*RefineOption, 0)
+	seen := map[string]bool{}
+	for _, ht := range s.hashTags(0) {
+		if len(ht.Words) <= len(s.Words) {
+			continue
+		}
+		word := ht.Words[len(s.Words)]
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		ret = append(ret, &RefineOption{
+			Word:  word,
+			Score: ht.Scores[len(s.Words)],
+			Best:  ht,
+		})
+		if count > 0 && len(ret) >= count {
+			break
+		}
+	}
+	return ret
+}
+
+// RefineSessions is a store of short-lived refinement sessions, indexed by token.
+type RefineSessions struct {
+	ttl      time.Duration
+	mu       sync.Mutex
+	sessions map[string]*RefineSession
+}
+
+func NewRefineSessions(ttl time.Duration) *RefineSessions {
+	return &RefineSessions{
+		ttl:      ttl,
+		sessions: make(map[string]*RefineSession),
+	}
+}
+
+// Start creates a new session for the given match lattice.
+func (rs *RefineSessions) Start(matches *StringMatches) (*RefineSession, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	session := &RefineSession{
+		Token:    hex.EncodeToString(b),
+		Matches:  matches,
+		Words:    []string{},
+		Scores:   []float64{},
+		suffixes: make(map[int][]*HashTag),
+		expires:  time.Now().Add(rs.ttl),
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.pruneExpired()
+	rs.sessions[session.Token] = session
+
+	return session, nil
+}
+
+// Get looks up a session by token, and extends its lifetime.
+func (rs *RefineSessions) Get(token string) (*RefineSession, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.pruneExpired()
+
+	session, ok := rs.sessions[token]
+	if !ok {
+		return nil, false
+	}
+	session.expires = time.Now().Add(rs.ttl)
+	return session, true
+}
+
+// Refine is the single entry point used by the frontends. If token is empty, it starts
+// a new session for input, otherwise it looks up the existing session. It then locks in
+// either word or the text up to boundary, if one of them is given.
+func (rs *RefineSessions) Refine(c *Completer, token string, input string, word string, boundary int) (*RefineSession, error) {
+	var session *RefineSession
+	if token == "" {
+		if len(input) > MaxInputLength {
+			return nil, fmt.Errorf("input is longer than %d characters", MaxInputLength)
+		}
+		var err error
+		session, err = rs.Start(c.ComputeStringMatches(input))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var ok bool
+		session, ok = rs.Get(token)
+		if !ok {
+			return nil, fmt.Errorf("unknown or expired session %s", token)
+		}
+	}
+
+	if word != "" {
+		if err := session.ChooseWord(word); err != nil {
+			return nil, err
+		}
+	} else if boundary > 0 {
+		if err := session.ChooseBoundary(boundary); err != nil {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+func (rs *RefineSessions) pruneExpired() {
+	now := time.Now()
+	for token, session := range rs.sessions {
+		if now.After(session.expires) {
+			delete(rs.sessions, token)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRefineSession(t *testing.T) {
+	trie := buildTrie([]string{"cleaner", "clean", "leaner", "er"})
+	completer := NewCompleter(trie, nil)
+	sessions := NewRefineSessions(time.Minute)
+
+	session, err := sessions.Refine(completer, "", "cleaner", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, session.Pos)
+	assert.Equal(t, "Cleaner", session.NextWords(0)[0].Word)
+
+	session, err = sessions.Refine(completer, session.Token, "", "clean", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, session.Pos)
+	assert.Equal(t, "er", session.Remaining())
+
+	hashtags := session.HashTags(0)
+	require.Equal(t, 2, len(hashtags))
+	assert.Equal(t, "CleanEr", hashtags[0].Tag())
+	assert.Equal(t, "CleanER", hashtags[1].Tag())
+
+	session, err = sessions.Refine(completer, session.Token, "", "", 7)
+	require.NoError(t, err)
+	assert.True(t, session.Done())
+	assert.Equal(t, []string{"Clean", "Er"}, session.HashTags(0)[0].Words)
+}
+
+func TestRefineSessionInvalidChoice(t *testing.T) {
+	trie := buildTrie([]string{"cleaner", "clean"})
+	completer := NewCompleter(trie, nil)
+	sessions := NewRefineSessions(time.Minute)
+
+	session, err := sessions.Refine(completer, "", "cleaner", "", 0)
+	require.NoError(t, err)
+
+	_, err = sessions.Refine(completer, session.Token, "", "lean", 0)
+	assert.Error(t, err)
+
+	_, err = sessions.Refine(completer, "unknown", "", "", 0)
+	assert.Error(t, err)
+}
+
+func TestRefineSessionConcurrentState(t *testing.T) {
+	trie := buildTrie([]string{"pen", "is", "land", "island"})
+	completer := NewCompleter(trie, nil)
+	sessions := NewRefineSessions(time.Minute)
+
+	session, err := sessions.Refine(completer, "", "penislandpenisland", "", 0)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for pos := 3; pos <= len("penislandpenisland"); pos += 3 {
+			_ = session.ChooseBoundary(pos)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		state := session.State()
+		assert.Equal(t, "penislandpenisland"[state.Pos:], state.Remaining)
+		assert.Equal(t, state.Pos/3, len(state.Words))
+	}
+	<-done
+
+	state := session.State()
+	assert.True(t, state.Done)
+	state.Words[0] = "Changed"
+	assert.Equal(t, "Pen", session.State().Words[0])
+}
@@ -0,0 +1,319 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Reranking documents how a re-ranker changed the score of a hashtag.
+type Reranking struct {
+	Reranker string
+	// Factor multiplies the score of the hashtag
+	Factor float64
+	Reason string
+}
+
+// Reranked returns a copy of the hashtag whose score is multiplied by factor.
+func (ht *HashTag) Reranked(reranker string, factor float64, reason string) *HashTag {
+	ret := *ht
+	ret.Rerankings = append(ht.Rerankings[:len(ht.Rerankings):len(ht.Rerankings)], &Reranking{
+		Reranker: reranker,
+		Factor:   factor,
+		Reason:   reason,
+	})
+	return &ret
+}
+
+// Reranker adjusts the candidates of an input once the search is done. It can change
+// their scores through HashTag.Reranked, drop them or add new ones, and doesn't need
+// to keep them sorted.
+type Reranker interface {
+	Name() string
+	Rerank(input string, hashTags []*HashTag) []*HashTag
+}
+
+// RerankChain applies re-rankers one after the other, so that the ranking policy of
+// a deployment is kept out of the search.
+type RerankChain []Reranker
+
+// Rerank runs the chain over the candidates and sorts them again.
+func (rc RerankChain) Rerank(input string, hashTags []*HashTag) []*HashTag {
+	if len(rc) == 0 {
+		return hashTags
+	}
+	for _, r := range rc {
+		hashTags = r.Rerank(input, hashTags)
+	}
+	SortHashTags(hashTags)
+	return hashTags
+}
+
+// Revision changes each time one of the re-rankers of the chain changes, see
+// OverrideReranker.Revision. The suggestions computed before are stale.
+func (rc RerankChain) Revision() uint64 {
+	var ret uint64
+	for _, r := range rc {
+		if revisioned, ok := r.(interface{ Revision() uint64 }); ok {
+			ret += revisioned.Revision()
+		}
+	}
+	return ret
+}
+
+// DefaultBlocklistFactor is the factor applied by a blocklist to hashtags containing
+// a blocked word. It demotes them below most of the others without removing them.
+const DefaultBlocklistFactor = 0.1
+
+// WordReranker multiplies the score of the hashtags containing some words, for example
+// to demote the words of a blocklist, or to boost the words a user often uses.
+type WordReranker struct {
+	name    string
+	Factors map[string]float64
+}
+
+func NewWordReranker(name string, factors map[string]float64) *WordReranker {
+	return &WordReranker{
+		name:    name,
+		Factors: factors,
+	}
+}
+
+// NewBlocklistReranker demotes the hashtags containing any of words by factor.
+func NewBlocklistReranker(words []string, factor float64) *WordReranker {
+	factors := make(map[string]float64, len(words))
+	for _, w := range words {
+		factors[strings.ToLower(w)] = factor
+	}
+	return NewWordReranker("blocklist", factors)
+}
+
+func (r *WordReranker) Name() string {
+	return r.name
+}
+
+func (r *WordReranker) Rerank(input string, hashTags []*HashTag) []*HashTag {
+	for i, ht := range hashTags {
+		for _, w := range ht.Words {
+			w = strings.ToLower(w)
+			if factor, ok := r.Factors[w]; ok {
+				ht = ht.Reranked(r.name, factor, fmt.Sprintf("contains %s", w))
+			}
+		}
+		hashTags[i] = ht
+	}
+	return hashTags
+}
+
+// OverrideReranker puts a curated segmentation first for some inputs, adding it to
+// the candidates if the search didn't find it. The overrides set at runtime are
+// persisted in its store, if it has one. It is safe for concurrent use.
+type OverrideReranker struct {
+	// Overrides maps lowercase inputs to the words of their curated segmentation
+	Overrides map[string][]string
+
+	mu sync.RWMutex
+	// revision counts the overrides set or deleted since the reranker was created
+	revision uint64
+	// store persists the overrides, it can be nil
+	store Store
+}
+
+func NewOverrideReranker(overrides map[string][]string) *OverrideReranker {
+	return &OverrideReranker{
+		Overrides: overrides,
+	}
+}
+
+// LoadOverrideReranker loads the overrides recorded in store, and records the new ones
+// in it. The given overrides, such as the ones of a file, take precedence over the
+// stored ones.
+func LoadOverrideReranker(store Store, overrides map[string][]string) (*OverrideReranker, error) {
+	stored, err := store.Overrides()
+	if err != nil {
+		return nil, err
+	}
+	for input, words := range overrides {
+		stored[strings.ToLower(input)] = words
+	}
+	r := NewOverrideReranker(stored)
+	r.store = store
+	return r, nil
+}
+
+// Set makes words the curated segmentation of input. The words have to spell out the
+// input, ignoring case, and are capitalized like the words of the suggestions.
+func (r *OverrideReranker) Set(input string, words []string) error {
+	if !strings.EqualFold(strings.Join(words, ""), input) || input == "" {
+		return fmt.Errorf("%s is not a segmentation of %s", strings.Join(words, " "), input)
+	}
+	capitalized := make([]string, len(words))
+	for i, w := range words {
+		capitalized[i] = capitalize(w)
+	}
+	words = capitalized
+	if r.store != nil {
+		err := r.store.SetOverride(input, words)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Overrides == nil {
+		r.Overrides = make(map[string][]string)
+	}
+	r.Overrides[strings.ToLower(input)] = words
+	r.revision++
+	return nil
+}
+
+// Delete removes the curated segmentation of input.
+func (r *OverrideReranker) Delete(input string) error {
+	if r.store != nil {
+		err := r.store.DeleteOverride(input)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.Overrides, strings.ToLower(input))
+	r.revision++
+	return nil
+}
+
+// List returns a copy of the curated segmentations, by lowercase input.
+func (r *OverrideReranker) List() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ret := make(map[string][]string, len(r.Overrides))
+	for input, words := range r.Overrides {
+		ret[input] = words
+	}
+	return ret
+}
+
+// Revision changes each time an override is set or deleted.
+func (r *OverrideReranker) Revision() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.revision
+}
+
+func (r *OverrideReranker) Name() string {
+	return "override"
+}
+
+func (r *OverrideReranker) Rerank(input string, hashTags []*HashTag) []*HashTag {
+	r.mu.RLock()
+	words, ok := r.Overrides[strings.ToLower(input)]
+	r.mu.RUnlock()
+	if !ok || len(hashTags) == 0 {
+		return hashTags
+	}
+
+	SortHashTags(hashTags)
+	best := hashTags[0].Score()
+	tag := strings.Join(words, "")
+	for i, ht := range hashTags {
+		if strings.EqualFold(ht.Tag(), tag) {
+			hashTags = append(hashTags[:i], hashTags[i+1:]...)
+			break
+		}
+	}
+
+	// the curated hashtag gets the best score, and a factor that puts it above it
+	scores := make([]float64, len(words))
+	for i := range scores {
+		scores[i] = best
+	}
+	curated := NewHashTag(words, scores).Reranked(r.Name(), 2, "curated segmentation")
+	return append([]*HashTag{curated}, hashTags...)
+}
+
+// DefaultDiversityFactor demotes a hashtag once for each better hashtag starting with
+// the same word.
+const DefaultDiversityFactor = 0.9
+
+// DiversityReranker demotes the hashtags that start with the same word as better ones,
+// so that the first suggestions show different readings of the input.
+type DiversityReranker struct {
+	Factor float64
+}
+
+func NewDiversityReranker(factor float64) *DiversityReranker {
+	return &DiversityReranker{
+		Factor: factor,
+	}
+}
+
+func (r *DiversityReranker) Name() string {
+	return "diversity"
+}
+
+func (r *DiversityReranker) Rerank(input string, hashTags []*HashTag) []*HashTag {
+	SortHashTags(hashTags)
+	seen := make(map[string]int)
+	for i, ht := range hashTags {
+		if len(ht.Words) == 0 {
+			continue
+		}
+		first := strings.ToLower(ht.Words[0])
+		factor := 1.0
+		for j := 0; j < seen[first]; j++ {
+			factor *= r.Factor
+		}
+		if seen[first] > 0 {
+			hashTags[i] = ht.Reranked(r.Name(), factor, fmt.Sprintf("%d better hashtags start with %s", seen[first], first))
+		}
+		seen[first]++
+	}
+	return hashTags
+}
+
+// LoadBlocklist loads a file of words to demote, one per line. Empty lines and lines
+// starting with # are ignored.
+func LoadBlocklist(path string) ([]string, error) {
+	return readLines(path)
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ret := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ret = append(ret, line)
+	}
+	return ret, scanner.Err()
+}
+
+// LoadOverrides loads a file of curated segmentations, one CamelCase hashtag per line
+// without its #, such as "ExpertsExchange". Empty lines and lines starting with #
+// are ignored.
+func LoadOverrides(path string) (map[string][]string, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string][]string, len(lines))
+	for _, line := range lines {
+		ret[strings.ToLower(line)] = SplitCamelCase(line)
+	}
+	return ret, nil
+}
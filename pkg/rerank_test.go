@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBlocklistReranker(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"who", "whore", "represents", "presents"}), map[string]int{"whore": 100000})
+	suggestions := completer.Suggest("whorepresents", SuggestOptions{})
+	require.Len(t, suggestions.HashTags, 2)
+	assert.Equal(t, "WhorePresents", suggestions.HashTags[0].Tag())
+
+	completer.Rerankers = RerankChain{NewBlocklistReranker([]string{"whore"}, DefaultBlocklistFactor)}
+	suggestions = completer.Suggest("whorepresents", SuggestOptions{})
+	require.Len(t, suggestions.HashTags, 2)
+	assert.Equal(t, "WhoRepresents", suggestions.HashTags[0].Tag())
+	demoted := suggestions.HashTags[1]
+	require.Len(t, demoted.Rerankings, 1)
+	assert.Equal(t, "blocklist", demoted.Rerankings[0].Reranker)
+	assert.Equal(t, "contains whore", demoted.Rerankings[0].Reason)
+}
+
+func TestOverrideReranker(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"pen", "island", "is", "land", "penis"}), nil)
+	completer.Rerankers = RerankChain{NewOverrideReranker(map[string][]string{
+		"penisland":       {"Pen", "Island"},
+		"expertsexchange": {"Experts", "Exchange"},
+	})}
+
+	suggestions := completer.Suggest("penisland", SuggestOptions{Count: 1})
+	require.Len(t, suggestions.HashTags, 1)
+	assert.Equal(t, "PenIsland", suggestions.HashTags[0].Tag())
+	assert.Equal(t, "override", suggestions.HashTags[0].Rerankings[0].Reranker)
+}
+
+func TestDiversityReranker(t *testing.T) {
+	hashTags := []*HashTag{
+		NewHashTag([]string{"Who", "Re", "Presents"}, []float64{10, 10, 10}),
+		NewHashTag([]string{"Who", "Represents"}, []float64{10, 9.5}),
+		NewHashTag([]string{"Whore", "Presents"}, []float64{9, 9}),
+	}
+	hashTags = RerankChain{NewDiversityReranker(0.5)}.Rerank("whorepresents", hashTags)
+	assert.Equal(t, "WhoRePresents", hashTags[0].Tag())
+	assert.Equal(t, "WhorePresents", hashTags[1].Tag())
+	assert.Equal(t, "WhoRepresents", hashTags[2].Tag())
+}
+
+func TestStoredOverrideReranker(t *testing.T) {
+	store := NewMemoryStore()
+	require.NoError(t, store.SetOverride("penisland", []string{"Penis", "Land"}))
+	require.NoError(t, store.SetOverride("fediverse", []string{"Fedi", "Verse"}))
+
+	reranker, err := LoadOverrideReranker(store, map[string][]string{"penisland": {"Pen", "Island"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"penisland": {"Pen", "Island"}, "fediverse": {"Fedi", "Verse"}}, reranker.List())
+
+	completer := NewCompleter(buildWordsTrie([]string{"pen", "island", "is", "land", "penis", "who", "represents"}), nil)
+	completer.Cache = NewSuggestionCache(10)
+	completer.Rerankers = RerankChain{reranker}
+	assert.Equal(t, "PenIsland", completer.Suggest("penisland", SuggestOptions{Count: 1}).HashTags[0].Tag())
+
+	assert.Error(t, reranker.Set("penisland", []string{"Pen"}))
+	require.NoError(t, reranker.Set("PenIsland", []string{"Pen", "Is", "Land"}))
+	assert.Equal(t, "PenIsLand", completer.Suggest("penisland", SuggestOptions{Count: 1}).HashTags[0].Tag())
+	require.NoError(t, reranker.Delete("fediverse"))
+	require.NoError(t, reranker.Set("whorepresents", []string{"who", "represents"}))
+	assert.Equal(t, "WhoRepresents", completer.Suggest("whorepresents", SuggestOptions{Count: 1}).HashTags[0].Tag())
+
+	overrides, err := store.Overrides()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"penisland": {"Pen", "Is", "Land"}, "whorepresents": {"Who", "Represents"}}, overrides)
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TextFormat string
+
+const (
+	PlainText TextFormat = "text"
+	Markdown  TextFormat = "markdown"
+	HTML      TextFormat = "html"
+)
+
+func ParseTextFormat(s string) (TextFormat, error) {
+	switch TextFormat(strings.ToLower(s)) {
+	case "", PlainText:
+		return PlainText, nil
+	case Markdown, "md":
+		return Markdown, nil
+	case HTML:
+		return HTML, nil
+	default:
+		return "", fmt.Errorf("unknown text format %s", s)
+	}
+}
+
+var (
+	hashTagRegexp = regexp.MustCompile(`#[\p{L}\p{N}_]+`)
+	urlRegexp     = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+	mentionRegexp = regexp.MustCompile(`@[\p{L}\p{N}_.]+(?:@[\p{L}\p{N}_.-]+)?`)
+
+	markdownFenceRegexp = regexp.MustCompile("(?s)(?:```.*?(?:```|$)|~~~.*?(?:~~~|$))")
+	markdownCodeRegexp  = regexp.MustCompile("`[^`\n]*`")
+	markdownLinkRegexp  = regexp.MustCompile(`\]\([^)\s]*\)`)
+
+	htmlCodeRegexp    = regexp.MustCompile(`(?is)<(code|pre)\b[^>]*>.*?</(?:code|pre)>`)
+	htmlMentionRegexp = regexp.MustCompile(`(?is)<a\b[^>]*class="[^"]*\b(?:mention|hashtag)\b[^"]*"[^>]*>.*?</a>`)
+	htmlTagRegexp     = regexp.MustCompile(`<[^>]*>`)
+)
+
+// skippedRanges returns the byte ranges of text that can't contain hashtags
+// for the given format: URLs, mentions, code spans and markup.
+func skippedRanges(text string, format TextFormat) [][]int {
+	regexps := []*regexp.Regexp{urlRegexp, mentionRegexp}
+	switch format {
+	case Markdown:
+		regexps = append(regexps, markdownFenceRegexp, markdownCodeRegexp, markdownLinkRegexp)
+	case HTML:
+		regexps = append(regexps, htmlCodeRegexp, htmlMentionRegexp, htmlTagRegexp)
+	}
+
+	ret := make([][]int, 0)
+	for _, r := range regexps {
+		ret = append(ret, r.FindAllStringIndex(text, -1)...)
+	}
+	return ret
+}
+
+// FindHashTags returns the byte ranges of the hashtags (including the leading #) in text.
+// A hashtag has to start a word, contain at least one letter, and must not be
+// inside a URL, a mention or a code span.
+func FindHashTags(text string, format TextFormat) [][]int {
+	skipped := skippedRanges(text, format)
+	ret := make([][]int, 0)
+
+	for _, loc := range hashTagRegexp.FindAllStringIndex(text, -1) {
+		if loc[0] > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+			// & catches HTML entities such as &#39;
+			if unicode.IsLetter(prev) || unicode.IsDigit(prev) ||
+				strings.ContainsRune("_&/#", prev) {
+				continue
+			}
+		}
+		if strings.IndexFunc(text[loc[0]:loc[1]], unicode.IsLetter) < 0 {
+			continue
+		}
+
+		isSkipped := false
+		for _, s := range skipped {
+			if loc[0] < s[1] && loc[1] > s[0] {
+				isSkipped = true
+				break
+			}
+		}
+		if !isSkipped {
+			ret = append(ret, loc)
+		}
+	}
+
+	return ret
+}
+
+// HashTagRewrite records the replacement of a hashtag, with its offsets in bytes
+// in both the original and the rewritten text.
+type HashTagRewrite struct {
+	Original       string
+	Rewritten      string
+	Start          int
+	End            int
+	RewrittenStart int
+	RewrittenEnd   int
+}
+
+// Rewrite replaces every lowercase hashtag in text by its best CamelCase suggestion, as
+// Suggest ranks it with the default strategy. Hashtags that already contain uppercase
+// letters, or that can't be segmented, are left untouched. The rest of the post and the other hashtags are used as context
+// when segmenting each hashtag.
+func (c *Completer) Rewrite(text string, format TextFormat) (string, []*HashTagRewrite) {
+	locs := FindHashTags(text, format)
+	strategy, err := c.Strategy("")
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not load the default strategy, rewriting with the average score")
+	}
+
+	// the post without its hashtags is the context shared by all of them,
+	// while each hashtag is only context for its siblings
+	var withoutTags strings.Builder
+	last := 0
+	siblingContexts := make([]*Context, len(locs))
+	for i, loc := range locs {
+		withoutTags.WriteString(text[last:loc[0]])
+		withoutTags.WriteString(" ")
+		last = loc[1]
+		siblingContexts[i] = c.NewContext("", []string{text[loc[0]:loc[1]]})
+	}
+	withoutTags.WriteString(text[last:])
+	postContext := c.NewContext(withoutTags.String(), nil)
+
+	rewrites := make([]*HashTagRewrite, 0)
+	var sb strings.Builder
+	last = 0
+
+	for i, loc := range locs {
+		original := text[loc[0]:loc[1]]
+		tag := original[1:]
+		if tag != strings.ToLower(tag) || len(tag) > MaxInputLength {
+			continue
+		}
+
+		siblings := make([]*Context, 0, len(locs)-1)
+		siblings = append(siblings, siblingContexts[:i]...)
+		siblings = append(siblings, siblingContexts[i+1:]...)
+		ctx := postContext.Merge(siblings...)
+
+		suggestions := c.Suggest(tag, SuggestOptions{Count: 1, Context: ctx, Strategy: strategy})
+		if len(suggestions.HashTags) == 0 {
+			continue
+		}
+		rewritten := "#" + suggestions.HashTags[0].Tag()
+
+		sb.WriteString(text[last:loc[0]])
+		start := sb.Len()
+		sb.WriteString(rewritten)
+		last = loc[1]
+
+		rewrites = append(rewrites, &HashTagRewrite{
+			Original:       original,
+			Rewritten:      rewritten,
+			Start:          loc[0],
+			End:            loc[1],
+			RewrittenStart: start,
+			RewrittenEnd:   sb.Len(),
+		})
+	}
+	sb.WriteString(text[last:])
+
+	return sb.String(), rewrites
+}
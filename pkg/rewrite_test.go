@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func findHashTagStrings(text string, format TextFormat) []string {
+	ret := []string{}
+	for _, loc := range FindHashTags(text, format) {
+		ret = append(ret, text[loc[0]:loc[1]])
+	}
+	return ret
+}
+
+func TestFindHashTags(t *testing.T) {
+	assert.Equal(t,
+		[]string{"#cleaner", "#Clean"},
+		findHashTagStrings("#cleaner and #Clean, not https://example.com/#anchor, @me#you or #1234", PlainText))
+
+	assert.Equal(t,
+		[]string{"#cleaner"},
+		findHashTagStrings("#cleaner `#code` [link](#anchor)\n```\n#fenced\n```\n", Markdown))
+
+	assert.Equal(t,
+		[]string{"#cleaner"},
+		findHashTagStrings(`<p>#cleaner &#39;<code>#code</code> <a href="/tags/a" class="mention hashtag">#<span>a</span></a></p>`, HTML))
+}
+
+func TestRewrite(t *testing.T) {
+	trie := buildTrie([]string{"cleaner", "clean", "leaner", "this"})
+	completer := NewCompleter(trie, nil)
+
+	text := "so #thiscleaner, but #ThisCleaner and http://x.org/#thiscleaner"
+	rewritten, rewrites := completer.Rewrite(text, PlainText)
+	assert.Equal(t, "so #ThisCleaner, but #ThisCleaner and http://x.org/#thiscleaner", rewritten)
+
+	require.Equal(t, 1, len(rewrites))
+	r := rewrites[0]
+	assert.Equal(t, "#thiscleaner", text[r.Start:r.End])
+	assert.Equal(t, "#ThisCleaner", rewritten[r.RewrittenStart:r.RewrittenEnd])
+}
+
+func TestRewriteRerankers(t *testing.T) {
+	completer := NewCompleter(buildTrie([]string{"pen", "island", "penis", "land"}), nil)
+	rewritten, _ := completer.Rewrite("#penisland", PlainText)
+	assert.Equal(t, "#PenIsland", rewritten)
+
+	// the re-rankers and the selections of Suggest apply to the rewritten hashtags
+	completer.Rerankers = RerankChain{NewBlocklistReranker([]string{"island"}, DefaultBlocklistFactor)}
+	rewritten, _ = completer.Rewrite("#penisland", PlainText)
+	assert.Equal(t, "#PenisLand", rewritten)
+
+	completer.Rerankers = nil
+	_, err := completer.Feedback.Select("penisland", []string{"Penis", "Land"})
+	require.NoError(t, err)
+	rewritten, _ = completer.Rewrite("#penisland and #pen", PlainText)
+	assert.Equal(t, "#PenisLand and #Pen", rewritten)
+}
@@ -0,0 +1,282 @@
+package pkg
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSearchResults stops the search once that many segmentations have been found.
+const maxSearchResults = 400
+
+// segment is a word of the segmentations explored by the search. Segments are linked
+// through next, so that a segmentation shares its words with all the others instead
+// of copying them: the words before a position are linked backwards, from the last
+// one to the first, and the words after it forwards.
+type segment struct {
+	match *Match
+	// next is the index of the next segment of the chain in the arena, -1 ending it
+	next int32
+	// words is the number of words of the chain starting at this segment
+	words int32
+}
+
+// searchEntry is a word still to explore, following the words of prefix.
+type searchEntry struct {
+	prefix int32
+	match  *Match
+}
+
+// searchResult is a segmentation of the whole input: the words of prefix, then the
+// words of suffix, whose first word is the last one that was explored.
+type searchResult struct {
+	prefix int32
+	suffix int32
+	score  float64
+}
+
+// searchArena holds the state of a search. Arenas are pooled, so that the search only
+// allocates when it explores more than the searches before it, and only the hashtags
+// returned by materialize are allocated.
+type searchArena struct {
+	segments []segment
+	stack    []searchEntry
+	// cache holds the suffix segments found for each cache key, see StringMatches.cacheKey
+	cache   [][]int32
+	results []searchResult
+	// order sorts the results without moving them
+	order []int32
+	// scores and chain are scratch space to score a result
+	scores []float64
+	chain  []int32
+	// tags holds the tags of the results compared when breaking ties, tagSpans[i] is
+	// where the tag of result i is in tags, its start is -1 if it wasn't built
+	tags     []byte
+	tagSpans [][2]int
+	// capitalized holds the capitalized words, so that each is only built once
+	capitalized map[*Match]string
+}
+
+var searchArenas = sync.Pool{
+	New: func() interface{} {
+		return &searchArena{capitalized: make(map[*Match]string)}
+	},
+}
+
+func (a *searchArena) reset(cacheSize int) {
+	a.segments = a.segments[:0]
+	a.stack = a.stack[:0]
+	a.results = a.results[:0]
+	a.tags = a.tags[:0]
+	for k := range a.capitalized {
+		delete(a.capitalized, k)
+	}
+	if cap(a.cache) < cacheSize {
+		a.cache = append(a.cache[:cap(a.cache)], make([][]int32, cacheSize-cap(a.cache))...)
+	}
+	a.cache = a.cache[:cacheSize]
+	for i := range a.cache {
+		a.cache[i] = a.cache[i][:0]
+	}
+}
+
+func (a *searchArena) push(match *Match, next int32) int32 {
+	a.segments = append(a.segments, segment{match: match, next: next, words: a.words(next) + 1})
+	return int32(len(a.segments) - 1)
+}
+
+// words returns the number of words of the chain starting at s.
+func (a *searchArena) words(s int32) int32 {
+	if s < 0 {
+		return 0
+	}
+	return a.segments[s].words
+}
+
+// cacheKey is the key of the suffixes starting at pos. With a maximum number of words,
+// they depend on how many words are left to use, so the cache is keyed on both.
+func (sm *StringMatches) cacheKey(pos int, words int32) int {
+	if sm.MaxWords <= 0 {
+		return pos
+	}
+	return pos*(sm.MaxWords+1) + sm.MaxWords - int(words)
+}
+
+func (sm *StringMatches) cacheSize() int {
+	if sm.MaxWords <= 0 {
+		return len(sm.String)
+	}
+	return len(sm.String) * (sm.MaxWords + 1)
+}
+
+// search explores the segmentations of the input from byte position pos depth first,
+// the best scored words first, and records them in the results of the arena.
+//
+// The suffixes found from a position are cached, so that reaching the position again
+// through other words reuses them instead of exploring it again.
+func (sm *StringMatches) search(a *searchArena, pos int) {
+	a.reset(sm.cacheSize())
+
+	appendResult := func(cur searchEntry, suffix int32) {
+		prefixWords := a.words(cur.prefix)
+		if sm.MaxWords > 0 && int(prefixWords+1+a.words(suffix)) > sm.MaxWords {
+			return
+		}
+		key := sm.cacheKey(cur.match.Pos, prefixWords)
+		s := a.push(cur.match, suffix)
+		a.cache[key] = append(a.cache[key], s)
+		a.results = append(a.results, searchResult{prefix: cur.prefix, suffix: s})
+	}
+
+	// the matches are pushed in reverse order, to have the highest weight on top of the stack
+	pushMatches := func(prefix int32, pos int) {
+		matches := sm.AllMatches[pos]
+		for i := len(matches) - 1; i >= 0; i-- {
+			a.stack = append(a.stack, searchEntry{prefix: prefix, match: matches[i]})
+		}
+	}
+
+	if pos < len(sm.AllMatches) {
+		pushMatches(-1, pos)
+	}
+
+	for len(a.stack) > 0 && len(a.results) <= maxSearchResults {
+		cur := a.stack[len(a.stack)-1]
+		a.stack = a.stack[:len(a.stack)-1]
+
+		nextPos := cur.match.Pos + len(cur.match.Match)
+		if nextPos >= len(sm.String) {
+			appendResult(cur, -1)
+			continue
+		}
+
+		prefixWords := a.words(cur.prefix)
+		if sm.MaxWords > 0 && int(prefixWords+1) >= sm.MaxWords {
+			// no words left for the rest of the input
+			continue
+		}
+
+		nextKey := sm.cacheKey(nextPos, prefixWords+1)
+		if len(a.cache[nextKey]) > 0 {
+			for _, suffix := range a.cache[nextKey] {
+				appendResult(cur, suffix)
+			}
+		} else if len(sm.AllMatches[nextPos]) > 0 {
+			pushMatches(a.push(cur.match, cur.prefix), nextPos)
+		}
+	}
+}
+
+// appendChain appends the segments of result r to a.chain, in the order of the input.
+func (a *searchArena) appendChain(r searchResult) {
+	a.chain = a.chain[:0]
+	for s := r.prefix; s >= 0; s = a.segments[s].next {
+		a.chain = append(a.chain, s)
+	}
+	for i, j := 0, len(a.chain)-1; i < j; i, j = i+1, j-1 {
+		a.chain[i], a.chain[j] = a.chain[j], a.chain[i]
+	}
+	for s := r.suffix; s >= 0; s = a.segments[s].next {
+		a.chain = append(a.chain, s)
+	}
+}
+
+// rank scores the results with strategy and sorts them into a.order, by descending
+// score and then by descending tag, like SortHashTags.
+func (a *searchArena) rank(strategy *Strategy) {
+	a.order = a.order[:0]
+	a.tagSpans = a.tagSpans[:0]
+	for i := range a.results {
+		a.appendChain(a.results[i])
+		a.scores = a.scores[:0]
+		for _, s := range a.chain {
+			a.scores = append(a.scores, a.segments[s].match.Score)
+		}
+		a.results[i].score = strategy.Combine(a.scores)
+		a.order = append(a.order, int32(i))
+		a.tagSpans = append(a.tagSpans, [2]int{-1, -1})
+	}
+
+	sort.SliceStable(a.order, func(i, j int) bool {
+		ri, rj := a.order[i], a.order[j]
+		si, sj := a.results[ri].score, a.results[rj].score
+		if si == sj {
+			return bytes.Compare(a.tag(ri), a.tag(rj)) > 0
+		}
+		return si > sj
+	})
+}
+
+// tag returns the tag of result i, building it the first time.
+func (a *searchArena) tag(i int32) []byte {
+	span := &a.tagSpans[i]
+	if span[0] < 0 {
+		span[0] = len(a.tags)
+		a.appendChain(a.results[i])
+		for _, s := range a.chain {
+			a.tags = appendCapitalized(a.tags, a.segments[s].match.Match)
+		}
+		span[1] = len(a.tags)
+	}
+	return a.tags[span[0]:span[1]]
+}
+
+func appendCapitalized(b []byte, s string) []byte {
+	if len(s) == 0 {
+		return b
+	}
+	if s[0] < utf8.RuneSelf {
+		c := s[0]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		return append(append(b, c), s[1:]...)
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	b = utf8.AppendRune(b, unicode.ToUpper(r))
+	return append(b, s[size:]...)
+}
+
+// materialize builds the hashtags of the first count ranked results, or all of them if
+// count is 0. The words and the scores of all the hashtags are allocated at once.
+func (a *searchArena) materialize(count int, strategy *Strategy) []*HashTag {
+	if count <= 0 || count > len(a.order) {
+		count = len(a.order)
+	}
+
+	total := 0
+	for _, i := range a.order[:count] {
+		r := a.results[i]
+		total += int(a.words(r.prefix) + a.words(r.suffix))
+	}
+	words := make([]string, 0, total)
+	scores := make([]float64, 0, total)
+	hashTags := make([]HashTag, count)
+	ret := make([]*HashTag, count)
+
+	for k, i := range a.order[:count] {
+		a.appendChain(a.results[i])
+		start := len(words)
+		for _, s := range a.chain {
+			m := a.segments[s].match
+			w, ok := a.capitalized[m]
+			if !ok {
+				w = capitalize(m.Match)
+				a.capitalized[m] = w
+			}
+			words = append(words, w)
+			scores = append(scores, m.Score)
+		}
+		end := len(words)
+		// the slices are capped, so that appending to the words of a hashtag copies them
+		hashTags[k] = HashTag{
+			Words:    words[start:end:end],
+			Scores:   scores[start:end:end],
+			Strategy: strategy,
+		}
+		ret[k] = &hashTags[k]
+	}
+	return ret
+}
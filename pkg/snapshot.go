@@ -0,0 +1,427 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	ahocorasick "github.com/BobuSumisu/aho-corasick"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"unsafe"
+)
+
+// A lexicon snapshot is the compiled dictionary matcher, the word frequencies and the casing
+// of a lexicon, laid out so that the matcher tables can be mapped in memory as they are:
+//
+//	header (snapshotHeaderSize bytes)
+//	the tables of the matcher, in the byte order of the machine:
+//	  dict, trans, failLink, dictLink and pattern of an aho-corasick trie, as int64
+//	  base and check of a double-array trie as int32, then terminal as uint64
+//	frequencies and casing, as uvarint-prefixed strings sorted by word
+//
+// The checksum of the header is the SHA-256 of everything that follows it.
+
+const (
+	snapshotMagic         = "MAJLEXSN"
+	snapshotFormatVersion = 1
+	// snapshotByteOrder tells whether the tables were written with the byte order of the machine
+	snapshotByteOrder  = 0x0102030405060708
+	snapshotHeaderSize = 128
+)
+
+// snapshot matcher kinds, aho-corasick is 0 so that the snapshots written before double-array
+// tries were supported are still read
+const (
+	snapshotAhoCorasick = iota
+	snapshotDoubleArray
+)
+
+// snapshotTableSizes are the sizes of the table elements of each matcher kind
+var snapshotTableSizes = map[uint64][5]uint64{
+	snapshotAhoCorasick: {8, 256 * 8, 8, 8, 8},
+	snapshotDoubleArray: {4, 4, 8, 0, 0},
+}
+
+type snapshotHeader struct {
+	Magic     [8]byte
+	Format    uint64
+	ByteOrder uint64
+	Words     uint64
+	// Tables are the lengths of the tables of the matcher
+	Tables         [5]uint64
+	FrequencyBytes uint64
+	CasingBytes    uint64
+	Checksum       [sha256.Size]byte
+	Matcher        uint64
+}
+
+// trieTables mirrors the layout of ahocorasick.Trie, whose tables are not exported.
+// checkTrieLayout makes sure it still matches the version of the library we build with.
+type trieTables struct {
+	dict     []int64
+	trans    [][256]int64
+	failLink []int64
+	dictLink []int64
+	pattern  []int64
+}
+
+func checkTrieLayout() error {
+	trie := reflect.TypeOf(ahocorasick.Trie{})
+	tables := reflect.TypeOf(trieTables{})
+	if trie.Size() != tables.Size() || trie.NumField() != tables.NumField() {
+		return errors.New("unsupported aho-corasick trie layout")
+	}
+	for i := 0; i < trie.NumField(); i++ {
+		a, b := trie.Field(i), tables.Field(i)
+		if a.Name != b.Name || a.Type.String() != b.Type.String() || a.Offset != b.Offset {
+			return fmt.Errorf("unsupported aho-corasick trie layout: field %s", a.Name)
+		}
+	}
+	return nil
+}
+
+func int64Bytes(s []int64) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*8)
+}
+
+func int32Bytes(s []int32) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*4)
+}
+
+func uint64Bytes(s []uint64) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*8)
+}
+
+func transBytes(s [][256]int64) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*256*8)
+}
+
+func encodeStringPairs(m map[string]string) []byte {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	for _, k := range keys {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(k))))
+		buf.WriteString(k)
+		buf.Write(binary.AppendUvarint(nil, uint64(len(m[k]))))
+		buf.WriteString(m[k])
+	}
+	return buf.Bytes()
+}
+
+func decodeStringPairs(b []byte) (map[string]string, error) {
+	ret := make(map[string]string)
+	next := func() (string, error) {
+		n, l := binary.Uvarint(b)
+		if l <= 0 || uint64(len(b)-l) < n {
+			return "", errors.New("truncated string")
+		}
+		s := string(b[l : l+int(n)])
+		b = b[l+int(n):]
+		return s, nil
+	}
+	for len(b) > 0 {
+		k, err := next()
+		if err != nil {
+			return nil, err
+		}
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		ret[k] = v
+	}
+	return ret, nil
+}
+
+func encodeFrequencies(frequency map[string]int) []byte {
+	m := make(map[string]string, len(frequency))
+	for w, f := range frequency {
+		m[w] = string(binary.AppendUvarint(nil, uint64(f)))
+	}
+	return encodeStringPairs(m)
+}
+
+func decodeFrequencies(b []byte) (map[string]int, error) {
+	m, err := decodeStringPairs(b)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]int, len(m))
+	for w, v := range m {
+		f, n := binary.Uvarint([]byte(v))
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid frequency of %s", w)
+		}
+		ret[w] = int(f)
+	}
+	return ret, nil
+}
+
+// WriteLexiconSnapshot compiles lexicon into a snapshot at path, and returns its checksum.
+// The snapshot is written next to path and renamed, so that readers never see a partial file.
+func WriteLexiconSnapshot(path string, lexicon *Lexicon) (string, error) {
+	header := snapshotHeader{
+		Format:    snapshotFormatVersion,
+		ByteOrder: snapshotByteOrder,
+		Words:     uint64(lexicon.Words),
+	}
+	copy(header.Magic[:], snapshotMagic)
+
+	var sections [][]byte
+	switch m := lexicon.Matcher.(type) {
+	case *AhoCorasickMatcher:
+		err := checkTrieLayout()
+		if err != nil {
+			return "", err
+		}
+		tables := (*trieTables)(unsafe.Pointer(m.Trie))
+		header.Matcher = snapshotAhoCorasick
+		header.Tables = [5]uint64{
+			uint64(len(tables.dict)),
+			uint64(len(tables.trans)),
+			uint64(len(tables.failLink)),
+			uint64(len(tables.dictLink)),
+			uint64(len(tables.pattern)),
+		}
+		sections = [][]byte{
+			int64Bytes(tables.dict),
+			transBytes(tables.trans),
+			int64Bytes(tables.failLink),
+			int64Bytes(tables.dictLink),
+			int64Bytes(tables.pattern),
+		}
+	case *DoubleArrayTrie:
+		header.Matcher = snapshotDoubleArray
+		header.Tables = [5]uint64{uint64(len(m.base)), uint64(len(m.check)), uint64(len(m.terminal))}
+		sections = [][]byte{
+			int32Bytes(m.base),
+			int32Bytes(m.check),
+			uint64Bytes(m.terminal),
+		}
+	default:
+		return "", fmt.Errorf("can't write a snapshot of a %T matcher", lexicon.Matcher)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	frequencies := encodeFrequencies(lexicon.Frequency)
+	casing := encodeStringPairs(lexicon.Casing)
+	header.FrequencyBytes = uint64(len(frequencies))
+	header.CasingBytes = uint64(len(casing))
+	sections = append(sections, frequencies, casing)
+
+	_, err = file.Seek(snapshotHeaderSize, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	w := bufio.NewWriterSize(io.MultiWriter(file, hash), 1<<20)
+	for _, section := range sections {
+		_, err = w.Write(section)
+		if err != nil {
+			return "", err
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		return "", err
+	}
+	copy(header.Checksum[:], hash.Sum(nil))
+
+	headerBuf := &bytes.Buffer{}
+	err = binary.Write(headerBuf, binary.LittleEndian, header)
+	if err != nil {
+		return "", err
+	}
+	headerBytes := make([]byte, snapshotHeaderSize)
+	copy(headerBytes, headerBuf.Bytes())
+	// the byte order mark is written like the tables, in the byte order of the machine
+	byteOrder := uint64(snapshotByteOrder)
+	copy(headerBytes[16:24], unsafe.Slice((*byte)(unsafe.Pointer(&byteOrder)), 8))
+	_, err = file.WriteAt(headerBytes, 0)
+	if err != nil {
+		return "", err
+	}
+	err = file.Sync()
+	if err != nil {
+		return "", err
+	}
+	err = file.Chmod(0644)
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(header.Checksum[:]), nil
+}
+
+// OpenLexiconSnapshot loads the snapshot at path. The matcher tables are mapped in memory
+// where the platform allows it, so that only the pages being used are read. The checksum
+// is only verified if verify is true, since that reads the whole file.
+func OpenLexiconSnapshot(path string, verify bool) (*Lexicon, error) {
+	data, release, err := mapSnapshot(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open snapshot %s: %w", path, err)
+	}
+	lexicon, err := parseSnapshot(data, release, verify)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	return lexicon, nil
+}
+
+func parseSnapshot(data []byte, release func(), verify bool) (*Lexicon, error) {
+	if len(data) < snapshotHeaderSize {
+		return nil, errors.New("truncated header")
+	}
+	header := snapshotHeader{}
+	err := binary.Read(bytes.NewReader(data[:snapshotHeaderSize]), binary.LittleEndian, &header)
+	if err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != snapshotMagic {
+		return nil, errors.New("not a lexicon snapshot")
+	}
+	if header.Format != snapshotFormatVersion {
+		return nil, fmt.Errorf("unsupported format version %d", header.Format)
+	}
+	byteOrder := uint64(0)
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(&byteOrder)), 8), data[16:24])
+	if byteOrder != snapshotByteOrder {
+		return nil, errors.New("snapshot written on a machine with another byte order")
+	}
+
+	sizes, ok := snapshotTableSizes[header.Matcher]
+	if !ok {
+		return nil, fmt.Errorf("unsupported matcher %d", header.Matcher)
+	}
+	if header.Matcher == snapshotAhoCorasick {
+		err = checkTrieLayout()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the lengths of the header are subtracted from the size of the payload rather than
+	// added up, so that corrupted lengths can't overflow
+	payload := data[snapshotHeaderSize:]
+	remaining := uint64(len(payload))
+	for i, n := range header.Tables {
+		if sizes[i] == 0 {
+			continue
+		}
+		if n > remaining/sizes[i] {
+			return nil, errors.New("truncated snapshot")
+		}
+		remaining -= n * sizes[i]
+	}
+	if header.FrequencyBytes > remaining || remaining-header.FrequencyBytes != header.CasingBytes {
+		return nil, errors.New("truncated snapshot")
+	}
+	if verify {
+		sum := sha256.Sum256(payload)
+		if sum != header.Checksum {
+			return nil, errors.New("checksum mismatch")
+		}
+	}
+
+	// the payload starts at a multiple of 8 bytes of an aligned mapping, and so do the tables
+	offset := 0
+	nextTable := func(i int) unsafe.Pointer {
+		n := header.Tables[i] * sizes[i]
+		if n == 0 {
+			return nil
+		}
+		ret := unsafe.Pointer(&payload[offset])
+		offset += int(n)
+		return ret
+	}
+	// tables keeps the mapping alive, it is released once the matcher is not used anymore
+	var tables interface{}
+	var matcher WordMatcher
+	switch header.Matcher {
+	case snapshotAhoCorasick:
+		t := &trieTables{
+			dict:     unsafeSlice((*int64)(nextTable(0)), header.Tables[0]),
+			trans:    unsafeSlice((*[256]int64)(nextTable(1)), header.Tables[1]),
+			failLink: unsafeSlice((*int64)(nextTable(2)), header.Tables[2]),
+			dictLink: unsafeSlice((*int64)(nextTable(3)), header.Tables[3]),
+			pattern:  unsafeSlice((*int64)(nextTable(4)), header.Tables[4]),
+		}
+		tables, matcher = t, NewAhoCorasickMatcher((*ahocorasick.Trie)(unsafe.Pointer(t)))
+	case snapshotDoubleArray:
+		d := &DoubleArrayTrie{
+			base:     unsafeSlice((*int32)(nextTable(0)), header.Tables[0]),
+			check:    unsafeSlice((*int32)(nextTable(1)), header.Tables[1]),
+			terminal: unsafeSlice((*uint64)(nextTable(2)), header.Tables[2]),
+		}
+		if len(d.base) != len(d.check) || len(d.check) == 0 || uint64(len(d.terminal)) != (header.Tables[1]+63)/64 {
+			return nil, errors.New("invalid double-array tables")
+		}
+		tables, matcher = d, d
+	}
+
+	frequency, err := decodeFrequencies(payload[offset : offset+int(header.FrequencyBytes)])
+	if err != nil {
+		return nil, err
+	}
+	offset += int(header.FrequencyBytes)
+	casing, err := decodeStringPairs(payload[offset:])
+	if err != nil {
+		return nil, err
+	}
+
+	runtime.SetFinalizer(tables, func(interface{}) { release() })
+
+	return &Lexicon{
+		Matcher:   matcher,
+		Frequency: frequency,
+		Casing:    casing,
+		Version:   hex.EncodeToString(header.Checksum[:])[:12],
+		Words:     int(header.Words),
+	}, nil
+}
+
+// unsafeSlice returns the slice of n elements at p, or an empty slice if p is nil.
+func unsafeSlice[T any](p *T, n uint64) []T {
+	if p == nil {
+		return []T{}
+	}
+	return unsafe.Slice(p, n)
+}
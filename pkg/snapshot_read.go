@@ -0,0 +1,34 @@
+//go:build !unix
+
+package pkg
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// mapSnapshot reads the file at path into memory aligned for the trie tables,
+// on the platforms where it can't be mapped.
+func mapSnapshot(path string) ([]byte, func(), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		return []byte{}, func() {}, nil
+	}
+
+	words := make([]uint64, (info.Size()+7)/8)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), info.Size())
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	_, err = io.ReadFull(file, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() {}, nil
+}
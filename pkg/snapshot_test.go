@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func matchedWords(lexicon *Lexicon, input string) []string {
+	ret := []string{}
+	lexicon.Matcher.MatchWords(input, func(pos int, word string) {
+		ret = append(ret, word)
+	})
+	return ret
+}
+
+func TestLexiconSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	dicts, frequencyPath := writeLexiconFiles(t, dir, "who\nwhore\nre\nrepresents\npresents\niPhone\nNASA\nParis\n",
+		"who\tPron\t2000\nrepresents\tVerb\t30\n")
+	lexicon, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	assert.Equal(t, "iPhone", lexicon.Casing["iphone"])
+	assert.Equal(t, "NASA", lexicon.Casing["nasa"])
+	assert.NotContains(t, lexicon.Casing, "paris")
+
+	path := filepath.Join(dir, "lexicon.snapshot")
+	checksum, err := WriteLexiconSnapshot(path, lexicon)
+	require.NoError(t, err)
+
+	snapshot, err := OpenLexiconSnapshot(path, true)
+	require.NoError(t, err)
+	assert.Equal(t, checksum[:12], snapshot.Version)
+	assert.Equal(t, lexicon.Words, snapshot.Words)
+	assert.Equal(t, lexicon.Frequency, snapshot.Frequency)
+	assert.Equal(t, lexicon.Casing, snapshot.Casing)
+	for _, input := range []string{"whorepresents", "iphoneparis", "xyz"} {
+		assert.Equal(t, matchedWords(lexicon, input), matchedWords(snapshot, input))
+	}
+
+	completer := NewDictionaryCompleter(NewLexiconDictionary(snapshot))
+	suggestions := completer.Suggest("whorepresents", SuggestOptions{})
+	assert.Equal(t, snapshot.Version, suggestions.LexiconVersion)
+	assert.Equal(t, "WhoRepresents", suggestions.HashTags[0].Tag())
+
+	// the same files give the same snapshot
+	again, err := WriteLexiconSnapshot(filepath.Join(dir, "again.snapshot"), lexicon)
+	require.NoError(t, err)
+	assert.Equal(t, checksum, again)
+}
+
+func TestDoubleArrayLexiconSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	dicts, frequencyPath := writeLexiconFiles(t, dir, "who\nwhore\nre\nrepresents\npresents\n",
+		"who\tPron\t2000\nrepresents\tVerb\t30\n")
+	lexicon, err := LoadLexicon(dicts, frequencyPath, DoubleArrayMatcherKind)
+	require.NoError(t, err)
+	require.IsType(t, &DoubleArrayTrie{}, lexicon.Matcher)
+	aho, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	assert.Equal(t, aho.Version, lexicon.Version)
+
+	path := filepath.Join(dir, "lexicon.snapshot")
+	_, err = WriteLexiconSnapshot(path, lexicon)
+	require.NoError(t, err)
+	snapshot, err := OpenLexiconSnapshot(path, true)
+	require.NoError(t, err)
+	require.IsType(t, &DoubleArrayTrie{}, snapshot.Matcher)
+	assert.Equal(t, lexicon.Frequency, snapshot.Frequency)
+	for _, input := range []string{"whorepresents", "xyz"} {
+		assert.Equal(t, matchedWords(lexicon, input), matchedWords(snapshot, input))
+	}
+
+	completer := NewDictionaryCompleter(NewLexiconDictionary(snapshot))
+	suggestions := completer.Suggest("whorepresents", SuggestOptions{})
+	assert.Equal(t, "WhoRepresents", suggestions.HashTags[0].Tag())
+}
+
+func TestInvalidLexiconSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	dicts, frequencyPath := writeLexiconFiles(t, dir, "pen\nisland\n", "pen\tNoun\t100\n")
+	lexicon, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "lexicon.snapshot")
+	_, err = WriteLexiconSnapshot(path, lexicon)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, corrupted, 0644))
+	_, err = OpenLexiconSnapshot(path, true)
+	assert.ErrorContains(t, err, "checksum")
+
+	require.NoError(t, os.WriteFile(path, data[:len(data)-8], 0644))
+	_, err = OpenLexiconSnapshot(path, false)
+	assert.ErrorContains(t, err, "truncated")
+
+	// table lengths whose sizes wrap around to the size of the payload
+	overflowing := append([]byte{}, data...)
+	tables := overflowing[32:]
+	binary.LittleEndian.PutUint64(tables, binary.LittleEndian.Uint64(tables)+1<<61)
+	require.NoError(t, os.WriteFile(path, overflowing, 0644))
+	_, err = OpenLexiconSnapshot(path, false)
+	assert.ErrorContains(t, err, "truncated")
+
+	_, err = OpenLexiconSnapshot(dicts[0], false)
+	assert.Error(t, err)
+}
+
+func TestSnapshotReloader(t *testing.T) {
+	dir := t.TempDir()
+	dicts, frequencyPath := writeLexiconFiles(t, dir, "pen\nisland\n", "pen\tNoun\t100\n")
+	lexicon, err := LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "lexicon.snapshot")
+	_, err = WriteLexiconSnapshot(path, lexicon)
+	require.NoError(t, err)
+
+	snapshot, err := OpenLexiconSnapshot(path, false)
+	require.NoError(t, err)
+	dictionary := NewLexiconDictionary(snapshot)
+	reloader := NewSnapshotReloader(dictionary, path)
+
+	dicts, frequencyPath = writeLexiconFiles(t, dir, "pen\nisland\npenis\nland\n", "pen\tNoun\t100\n")
+	lexicon, err = LoadLexicon(dicts, frequencyPath, AhoCorasickMatcherKind)
+	require.NoError(t, err)
+	checksum, err := WriteLexiconSnapshot(path, lexicon)
+	require.NoError(t, err)
+
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, checksum[:12], reloaded.Version)
+	assert.Equal(t, checksum[:12], dictionary.Version())
+	assert.Len(t, dictionary.FindMatches("penisland"), 4)
+}
@@ -0,0 +1,167 @@
+package pkg
+
+import (
+	ahocorasick "github.com/BobuSumisu/aho-corasick"
+	"regexp"
+	"sort"
+)
+
+// DictionarySource is the name of the source built from the dictionary trie of a Completer.
+const DictionarySource = "dictionary"
+
+// MatchSource generates candidate words for the lattice of an input. Several sources
+// can contribute to the same lattice, see MergeMatches.
+type MatchSource interface {
+	// Name is recorded as the Source of the matches, to tell where a word came from
+	Name() string
+	// FindMatches returns the scored words found in input. The Match of each word
+	// covers the bytes of input starting at Pos.
+	FindMatches(input string) []*Match
+}
+
+// StrategySource is a MatchSource whose words can be scored with a ranking strategy.
+// The words of the other sources keep their scores when a strategy is selected.
+type StrategySource interface {
+	MatchSource
+	// StrategyScore scores a word found by the source with strategy
+	StrategyScore(strategy *Strategy, word string) float64
+}
+
+// TrieSource finds the words of a dictionary trie, scored with WordScore.
+type TrieSource struct {
+	name      string
+	Matcher   WordMatcher
+	Frequency map[string]int
+}
+
+func NewTrieSource(name string, trie *ahocorasick.Trie, frequency map[string]int) *TrieSource {
+	return NewMatcherSource(name, NewAhoCorasickMatcher(trie), frequency)
+}
+
+// NewMatcherSource finds the words of matcher, such as a DoubleArrayTrie.
+func NewMatcherSource(name string, matcher WordMatcher, frequency map[string]int) *TrieSource {
+	return &TrieSource{
+		name:      name,
+		Matcher:   matcher,
+		Frequency: frequency,
+	}
+}
+
+// NewWordListSource builds a source out of a list of words, such as a gazetteer of
+// place names or the personal dictionary of a user.
+func NewWordListSource(name string, words []string, frequency map[string]int) *TrieSource {
+	builder := ahocorasick.NewTrieBuilder()
+	builder.AddStrings(words)
+	return NewTrieSource(name, builder.Build(), frequency)
+}
+
+func (s *TrieSource) Name() string {
+	return s.name
+}
+
+func (s *TrieSource) FindMatches(input string) []*Match {
+	ret := make([]*Match, 0)
+	s.Matcher.MatchWords(input, func(pos int, word string) {
+		ret = append(ret, &Match{
+			Match:  word,
+			Pos:    pos,
+			Score:  WordScore(word, s.Frequency),
+			Source: s.name,
+		})
+	})
+	return ret
+}
+
+// StrategyScore scores word with strategy and the frequencies of the source.
+func (s *TrieSource) StrategyScore(strategy *Strategy, word string) float64 {
+	return strategy.WordScore(word, s.Frequency)
+}
+
+// RegexpSource recognizes the tokens matching a regular expression, such as runs of
+// digits or dates, which no dictionary lists.
+type RegexpSource struct {
+	name   string
+	Regexp *regexp.Regexp
+	// Score scores a token, WordScore without frequencies is used if it is nil
+	Score func(token string) float64
+}
+
+func NewRegexpSource(name string, re *regexp.Regexp, score func(token string) float64) *RegexpSource {
+	return &RegexpSource{
+		name:   name,
+		Regexp: re,
+		Score:  score,
+	}
+}
+
+func (s *RegexpSource) Name() string {
+	return s.name
+}
+
+func (s *RegexpSource) FindMatches(input string) []*Match {
+	ret := make([]*Match, 0)
+	for _, loc := range s.Regexp.FindAllStringIndex(input, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		token := input[loc[0]:loc[1]]
+		score := 0.0
+		if s.Score != nil {
+			score = s.Score(token)
+		} else {
+			score = WordScore(token, nil)
+		}
+		ret = append(ret, &Match{
+			Match:  token,
+			Pos:    loc[0],
+			Score:  score,
+			Source: s.name,
+		})
+	}
+	return ret
+}
+
+// StrategyScore scores token with the Score function of the source if it has one,
+// and with strategy otherwise.
+func (s *RegexpSource) StrategyScore(strategy *Strategy, token string) float64 {
+	if s.Score != nil {
+		return s.Score(token)
+	}
+	return strategy.WordScore(token, nil)
+}
+
+// MergeMatches runs all the sources over input and groups their matches by position,
+// highest score first. When several sources find the same word at the same position,
+// only the highest scoring match is kept.
+func MergeMatches(input string, sources ...MatchSource) [][]*Match {
+	ret := make([][]*Match, len(input))
+
+	for _, source := range sources {
+		for _, m := range source.FindMatches(input) {
+			if m.Pos < 0 || len(m.Match) == 0 || m.Pos+len(m.Match) > len(input) {
+				continue
+			}
+
+			duplicate := false
+			for i, other := range ret[m.Pos] {
+				if other.Match == m.Match {
+					if m.Score > other.Score {
+						ret[m.Pos][i] = m
+					}
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				ret[m.Pos] = append(ret[m.Pos], m)
+			}
+		}
+	}
+
+	for _, ms_ := range ret {
+		sort.Slice(ms_, func(i, j int) bool {
+			return ms_[i].Score > ms_[j].Score
+		})
+	}
+	return ret
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+func TestMatchSources(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"world", "cup"}), nil)
+	assert.Empty(t, completer.ComputeStringMatches("worldcup2019").SuggestHashtags())
+
+	completer.AddSource(NewRegexpSource("number", regexp.MustCompile(`[0-9]+`), nil))
+	hashTags := completer.ComputeStringMatches("worldcup2019").SuggestHashtags()
+	require.NotEmpty(t, hashTags)
+	assert.Equal(t, "WorldCup2019", hashTags[0].Tag())
+
+	sm := completer.ComputeStringMatches("worldcup2019")
+	assert.Equal(t, DictionarySource, sm.AllMatches[0][0].Source)
+	assert.Equal(t, "number", sm.AllMatches[8][0].Source)
+}
+
+func TestMergeMatchesKeepsBestScore(t *testing.T) {
+	dictionary := NewTrieSource(DictionarySource, buildWordsTrie([]string{"cup"}), nil)
+	user := NewWordListSource("user", []string{"cup"}, map[string]int{"cup": 1000})
+
+	matches := MergeMatches("cup", dictionary, user)
+	require.Len(t, matches[0], 1)
+	assert.Equal(t, "user", matches[0][0].Source)
+	assert.Greater(t, matches[0][0].Score, WordScore("cup", nil))
+}
+
+func TestMatchSourcesWithStrategy(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"world", "cup"}), nil)
+	completer.AddSource(NewWordListSource("places", []string{"paris"}, map[string]int{"paris": 500}))
+	completer.AddSource(NewRegexpSource("number", regexp.MustCompile(`[0-9]+`), func(token string) float64 {
+		return 42
+	}))
+	strategy := DefaultStrategies()[LogProbabilityStrategy]
+
+	sm := completer.ComputeStringMatchesWith("worldcupparis2019", strategy)
+	scores := map[string]float64{}
+	for _, ms_ := range sm.AllMatches {
+		for _, m := range ms_ {
+			scores[m.Match] = m.Score
+		}
+	}
+	assert.Equal(t, strategy.WordScore("cup", nil), scores["cup"])
+	assert.Equal(t, strategy.WordScore("paris", map[string]int{"paris": 500}), scores["paris"])
+	assert.Equal(t, 42.0, scores["2019"])
+
+	// without the sources, only the dictionary words are rescored
+	sm = completer.ComputeStringMatches("paris2019")
+	sm.Rescore(strategy, nil)
+	assert.Equal(t, WordScore("paris", map[string]int{"paris": 500}), sm.AllMatches[0][0].Score)
+	assert.Equal(t, 42.0, sm.AllMatches[5][0].Score)
+}
@@ -0,0 +1,253 @@
+package pkg
+
+import (
+	_ "embed"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//go:embed data/acronyms.txt
+var acronymsFile string
+
+var acronyms = loadWordList(acronymsFile)
+
+// separatorNames are used to spell out the separators of handles and URLs.
+var separatorNames = map[rune]string{
+	'.': "dot",
+	'/': "slash",
+	'-': "dash",
+	'_': "underscore",
+	':': "colon",
+	'@': "at",
+	'?': "question mark",
+	'#': "hash",
+	'=': "equals",
+	'&': "and",
+	'+': "plus",
+	'~': "tilde",
+}
+
+var (
+	smallNumbers = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen",
+		"seventeen", "eighteen", "nineteen",
+	}
+	tensNumbers = []string{
+		"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety",
+	}
+	largeNumbers = []struct {
+		value int
+		name  string
+	}{
+		{1000000000, "billion"},
+		{1000000, "million"},
+		{1000, "thousand"},
+	}
+)
+
+// maxCardinalDigits is the longest number we read as a cardinal, longer ones are read
+// digit by digit.
+const maxCardinalDigits = 12
+
+func cardinal(n int) string {
+	for _, l := range largeNumbers {
+		if n >= l.value {
+			ret := cardinal(n/l.value) + " " + l.name
+			if n%l.value != 0 {
+				ret += " " + cardinal(n%l.value)
+			}
+			return ret
+		}
+	}
+	if n >= 100 {
+		ret := smallNumbers[n/100] + " hundred"
+		if n%100 != 0 {
+			ret += " " + cardinal(n%100)
+		}
+		return ret
+	}
+	if n >= 20 {
+		ret := tensNumbers[n/10]
+		if n%10 != 0 {
+			ret += " " + smallNumbers[n%10]
+		}
+		return ret
+	}
+	return smallNumbers[n]
+}
+
+func spellDigits(digits string) string {
+	parts := make([]string, len(digits))
+	for i, d := range digits {
+		parts[i] = smallNumbers[d-'0']
+	}
+	return strings.Join(parts, " ")
+}
+
+// isYear returns true for the 4 digit numbers that are read as years,
+// such as 1990 ("nineteen ninety") or 2019 ("twenty nineteen").
+func isYear(digits string) bool {
+	if len(digits) != 4 || digits[0] == '0' {
+		return false
+	}
+	n, err := strconv.Atoi(digits)
+	return err == nil && n >= 1100 && n < 2100
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return r < '0' || r > '9'
+	}) < 0
+}
+
+// VerbalizeNumber spells out a string of digits: years are read in pairs, numbers
+// with leading zeros or very long numbers digit by digit, and the others as cardinals.
+// Strings that aren't only made of digits are returned unchanged.
+func VerbalizeNumber(digits string) string {
+	if !isDigits(digits) {
+		return digits
+	}
+	if (len(digits) > 1 && digits[0] == '0') || len(digits) > maxCardinalDigits {
+		return spellDigits(digits)
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return spellDigits(digits)
+	}
+
+	if isYear(digits) {
+		hi, lo := n/100, n%100
+		switch {
+		case hi%10 == 0 && lo < 10:
+			// 2000, 2009
+			return cardinal(n)
+		case lo == 0:
+			return cardinal(hi) + " hundred"
+		case lo < 10:
+			return cardinal(hi) + " oh " + smallNumbers[lo]
+		default:
+			return cardinal(hi) + " " + cardinal(lo)
+		}
+	}
+
+	return cardinal(n)
+}
+
+// IsAcronym returns true if word should be read letter by letter: it is written in
+// uppercase, is a well known acronym, or doesn't contain any vowel.
+func IsAcronym(word string) bool {
+	if len(word) < 2 || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+		return false
+	}
+	if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsUpper(r) }) < 0 {
+		return true
+	}
+	lower := strings.ToLower(word)
+	if acronyms[lower] {
+		return true
+	}
+	return strings.IndexFunc(lower, func(r rune) bool {
+		return strings.ContainsRune("aeiouy", r) || !unicode.IsLetter(r)
+	}) < 0
+}
+
+func spellLetters(word string) string {
+	parts := make([]string, 0, len(word))
+	for _, r := range strings.ToUpper(word) {
+		parts = append(parts, string(r))
+	}
+	return strings.Join(parts, " ")
+}
+
+// spokenPart is a word or separator of a hashtag, along with how to say it.
+type spokenPart struct {
+	text   string
+	spoken string
+	// interpretAs is the SSML say-as hint, if any
+	interpretAs string
+	format      string
+	separator   bool
+}
+
+func (ht *HashTag) spokenParts() []*spokenPart {
+	parts := make([]*spokenPart, 0, len(ht.Words))
+	addSeparator := func(separator string) {
+		for _, r := range separator {
+			if name, ok := separatorNames[r]; ok {
+				parts = append(parts, &spokenPart{text: string(r), spoken: name, separator: true})
+			}
+		}
+	}
+
+	for i, w := range ht.Words {
+		if ht.Separators != nil {
+			addSeparator(ht.Separators[i])
+		}
+
+		part := &spokenPart{text: w}
+		switch {
+		case isDigits(w):
+			part.spoken = VerbalizeNumber(w)
+			switch {
+			case (len(w) > 1 && w[0] == '0') || len(w) > maxCardinalDigits:
+				part.interpretAs = "digits"
+			case isYear(w):
+				part.interpretAs, part.format = "date", "y"
+			default:
+				part.interpretAs = "cardinal"
+			}
+		case IsAcronym(w):
+			part.spoken = spellLetters(w)
+			part.interpretAs = "characters"
+		default:
+			part.spoken = strings.ToLower(w)
+		}
+		parts = append(parts, part)
+	}
+	if len(ht.Separators) > len(ht.Words) {
+		addSeparator(ht.Separators[len(ht.Words)])
+	}
+
+	return parts
+}
+
+// Spoken renders the hashtag the way it should be read out loud: its words
+// separated by spaces, numbers verbalized, acronyms spelled out letter by letter,
+// and its separators spelled out.
+func (ht *HashTag) Spoken() string {
+	parts := ht.spokenParts()
+	ret := make([]string, len(parts))
+	for i, p := range parts {
+		ret[i] = p.spoken
+	}
+	return strings.Join(ret, " ")
+}
+
+// SSML renders the hashtag as an SSML document for speech synthesizers, with
+// say-as hints for numbers and acronyms, and short breaks between the words.
+func (ht *HashTag) SSML() string {
+	var sb strings.Builder
+	sb.WriteString("<speak>")
+	for i, p := range ht.spokenParts() {
+		if i > 0 {
+			sb.WriteString(`<break strength="weak"/>`)
+		}
+		switch {
+		case p.separator:
+			sb.WriteString(html.EscapeString(p.spoken))
+		case p.interpretAs != "":
+			sb.WriteString(`<say-as interpret-as="` + p.interpretAs + `"`)
+			if p.format != "" {
+				sb.WriteString(` format="` + p.format + `"`)
+			}
+			sb.WriteString(">" + html.EscapeString(p.text) + "</say-as>")
+		default:
+			sb.WriteString(html.EscapeString(strings.ToLower(p.text)))
+		}
+	}
+	sb.WriteString("</speak>")
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVerbalizeNumber(t *testing.T) {
+	assert.Equal(t, "twenty nineteen", VerbalizeNumber("2019"))
+	assert.Equal(t, "nineteen ninety", VerbalizeNumber("1990"))
+	assert.Equal(t, "nineteen oh five", VerbalizeNumber("1905"))
+	assert.Equal(t, "nineteen hundred", VerbalizeNumber("1900"))
+	assert.Equal(t, "two thousand", VerbalizeNumber("2000"))
+	assert.Equal(t, "two thousand eight", VerbalizeNumber("2008"))
+	assert.Equal(t, "forty two", VerbalizeNumber("42"))
+	assert.Equal(t, "one hundred one", VerbalizeNumber("101"))
+	assert.Equal(t, "twelve thousand three hundred forty five", VerbalizeNumber("12345"))
+	assert.Equal(t, "zero zero seven", VerbalizeNumber("007"))
+
+	assert.Equal(t, "12a", VerbalizeNumber("12a"))
+	assert.Equal(t, "0x1f", VerbalizeNumber("0x1f"))
+	assert.Equal(t, "-5", VerbalizeNumber("-5"))
+	assert.Equal(t, "", VerbalizeNumber(""))
+}
+
+func TestIsAcronym(t *testing.T) {
+	assert.True(t, IsAcronym("NASA"))
+	assert.True(t, IsAcronym("Fbi"))
+	assert.True(t, IsAcronym("Html"))
+	assert.False(t, IsAcronym("Fishing"))
+	assert.False(t, IsAcronym("A"))
+	assert.False(t, IsAcronym("Sky"))
+}
+
+func TestSpoken(t *testing.T) {
+	ht := NewHashTag([]string{"World", "Cup", "2019", "Fbi"}, []float64{1, 1, 1, 1})
+	assert.Equal(t, "world cup twenty nineteen F B I", ht.Spoken())
+	assert.Equal(t,
+		`<speak>world<break strength="weak"/>cup<break strength="weak"/>`+
+			`<say-as interpret-as="date" format="y">2019</say-as><break strength="weak"/>`+
+			`<say-as interpret-as="characters">Fbi</say-as></speak>`,
+		ht.SSML())
+
+	handle := &HashTag{
+		Words:      []string{"Jane", "Doe", "1990"},
+		Scores:     []float64{1, 1, 1},
+		Separators: []string{"", "", "_", ""},
+	}
+	assert.Equal(t, "jane doe underscore nineteen ninety", handle.Spoken())
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store persists what the server learns while running: the selected suggestions and
+// the counts of their words, the dictionaries of the users, the curated overrides and
+// the words added to the dictionary.
+type Store interface {
+	// AddSelection counts words as the selected segmentation of input, along with each of
+	// the words. It returns how many times the segmentation was selected for input.
+	AddSelection(input string, words []string) (int, error)
+	// SelectionCounts returns the number of times each segmentation (see segmentationKey)
+	// was selected, by lowercase input
+	SelectionCounts() (map[string]map[string]int, error)
+	// WordCounts returns the number of selected suggestions each learned word was part of
+	WordCounts() (map[string]int, error)
+
+	AddUserWords(user string, words ...string) error
+	RemoveUserWords(user string, words ...string) error
+	// UserWords returns the personal dictionary of a user, sorted
+	UserWords(user string) ([]string, error)
+
+	// SetOverride sets the curated segmentation of an input, see OverrideReranker
+	SetOverride(input string, words []string) error
+	DeleteOverride(input string) error
+	// Overrides returns the curated segmentations by lowercase input
+	Overrides() (map[string][]string, error)
+
+	// SetDictionaryEntries records runtime changes of the dictionary, replacing the
+	// previous changes of the same words. Either all the entries are recorded, or none.
+	SetDictionaryEntries(entries ...DictionaryEntry) error
+	// DictionaryEntries returns the runtime changes of the dictionary, sorted by word
+	DictionaryEntries() ([]DictionaryEntry, error)
+
+	Close() error
+}
+
+// OpenStore opens the store described by spec, either "memory" or "bolt:<path>"
+// for a single file database.
+func OpenStore(spec string) (Store, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "bolt":
+		if path == "" {
+			return nil, fmt.Errorf("missing path in store %s", spec)
+		}
+		return OpenBoltStore(path)
+	default:
+		return nil, fmt.Errorf("unknown store %s", spec)
+	}
+}
+
+// MemoryStore keeps everything in memory, and loses it on restart.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	selections map[string]map[string]int
+	words      map[string]int
+	users      map[string]map[string]bool
+	overrides  map[string][]string
+	dictionary map[string]DictionaryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		selections: make(map[string]map[string]int),
+		words:      make(map[string]int),
+		users:      make(map[string]map[string]bool),
+		overrides:  make(map[string][]string),
+		dictionary: make(map[string]DictionaryEntry),
+	}
+}
+
+func (s *MemoryStore) AddSelection(input string, words []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range words {
+		s.words[strings.ToLower(w)]++
+	}
+	input = strings.ToLower(input)
+	if s.selections[input] == nil {
+		s.selections[input] = make(map[string]int)
+	}
+	key := segmentationKey(words)
+	s.selections[input][key]++
+	return s.selections[input][key], nil
+}
+
+func (s *MemoryStore) SelectionCounts() (map[string]map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make(map[string]map[string]int, len(s.selections))
+	for input, counts := range s.selections {
+		ret[input] = make(map[string]int, len(counts))
+		for k, v := range counts {
+			ret[input][k] = v
+		}
+	}
+	return ret, nil
+}
+
+func (s *MemoryStore) WordCounts() (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make(map[string]int, len(s.words))
+	for k, v := range s.words {
+		ret[k] = v
+	}
+	return ret, nil
+}
+
+func (s *MemoryStore) AddUserWords(user string, words ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.users[user] == nil {
+		s.users[user] = make(map[string]bool)
+	}
+	for _, w := range words {
+		s.users[user][strings.ToLower(w)] = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) RemoveUserWords(user string, words ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range words {
+		delete(s.users[user], strings.ToLower(w))
+	}
+	return nil
+}
+
+func (s *MemoryStore) UserWords(user string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make([]string, 0, len(s.users[user]))
+	for w := range s.users[user] {
+		ret = append(ret, w)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+func (s *MemoryStore) SetOverride(input string, words []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.overrides[strings.ToLower(input)] = words
+	return nil
+}
+
+func (s *MemoryStore) DeleteOverride(input string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.overrides, strings.ToLower(input))
+	return nil
+}
+
+func (s *MemoryStore) Overrides() (map[string][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make(map[string][]string, len(s.overrides))
+	for k, v := range s.overrides {
+		ret[k] = v
+	}
+	return ret, nil
+}
+
+func (s *MemoryStore) SetDictionaryEntries(entries ...DictionaryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		s.dictionary[entry.Word] = entry
+	}
+	return nil
+}
+
+func (s *MemoryStore) DictionaryEntries() ([]DictionaryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make([]DictionaryEntry, 0, len(s.dictionary))
+	for _, e := range s.dictionary {
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Word < ret[j].Word
+	})
+	return ret, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,267 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	bolt "go.etcd.io/bbolt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	boltMetaBucket       = []byte("meta")
+	boltSelectionsBucket = []byte("selections")
+	boltWordsBucket      = []byte("words")
+	boltUsersBucket      = []byte("users")
+	boltOverridesBucket  = []byte("overrides")
+	boltDictionaryBucket = []byte("dictionary")
+
+	boltVersionKey = []byte("version")
+)
+
+// boltMigrations upgrade the schema of a store, whose version is the number of migrations
+// applied to it. New migrations are appended to the list, existing ones never change.
+var boltMigrations = []func(tx *bolt.Tx) error{
+	// 1: selections hold a bucket of segmentation counts per input, words the counts of
+	// the learned words, users a bucket of words per user, and overrides the words
+	// of curated segmentations separated by spaces
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltSelectionsBucket, boltWordsBucket, boltUsersBucket, boltOverridesBucket} {
+			_, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 2: dictionary holds the runtime changes of the dictionary as JSON, by word
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltDictionaryBucket)
+		return err
+	},
+}
+
+// BoltStore keeps everything in a single bbolt database file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens or creates the database at path, and migrates it to the current schema.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("could not open store %s: %w", path, err)
+	}
+
+	err = db.Update(migrateBolt)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not migrate store %s: %w", path, err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func migrateBolt(tx *bolt.Tx) error {
+	meta, err := tx.CreateBucketIfNotExists(boltMetaBucket)
+	if err != nil {
+		return err
+	}
+
+	version := 0
+	if v := meta.Get(boltVersionKey); v != nil {
+		version = int(decodeCount(v))
+	}
+	if version > len(boltMigrations) {
+		return fmt.Errorf("schema version %d is newer than the supported version %d", version, len(boltMigrations))
+	}
+
+	for ; version < len(boltMigrations); version++ {
+		err = boltMigrations[version](tx)
+		if err != nil {
+			return fmt.Errorf("migration %d failed: %w", version+1, err)
+		}
+	}
+	return meta.Put(boltVersionKey, encodeCount(uint64(version)))
+}
+
+func encodeCount(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
+
+func decodeCount(b []byte) uint64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func incrementCount(b *bolt.Bucket, key []byte) (uint64, error) {
+	n := decodeCount(b.Get(key)) + 1
+	return n, b.Put(key, encodeCount(n))
+}
+
+func (s *BoltStore) AddSelection(input string, words []string) (int, error) {
+	count := uint64(0)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		wordsBucket := tx.Bucket(boltWordsBucket)
+		for _, w := range words {
+			_, err := incrementCount(wordsBucket, []byte(strings.ToLower(w)))
+			if err != nil {
+				return err
+			}
+		}
+
+		b, err := tx.Bucket(boltSelectionsBucket).CreateBucketIfNotExists([]byte(strings.ToLower(input)))
+		if err != nil {
+			return err
+		}
+		count, err = incrementCount(b, []byte(segmentationKey(words)))
+		return err
+	})
+	return int(count), err
+}
+
+func (s *BoltStore) SelectionCounts() (map[string]map[string]int, error) {
+	ret := make(map[string]map[string]int)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		selections := tx.Bucket(boltSelectionsBucket)
+		return selections.ForEach(func(input, _ []byte) error {
+			counts := make(map[string]int)
+			err := selections.Bucket(input).ForEach(func(k, v []byte) error {
+				counts[string(k)] = int(decodeCount(v))
+				return nil
+			})
+			ret[string(input)] = counts
+			return err
+		})
+	})
+	return ret, err
+}
+
+func (s *BoltStore) WordCounts() (map[string]int, error) {
+	ret := make(map[string]int)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltWordsBucket).ForEach(func(k, v []byte) error {
+			ret[string(k)] = int(decodeCount(v))
+			return nil
+		})
+	})
+	return ret, err
+}
+
+func (s *BoltStore) AddUserWords(user string, words ...string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(boltUsersBucket).CreateBucketIfNotExists([]byte(user))
+		if err != nil {
+			return err
+		}
+		for _, w := range words {
+			err = b.Put([]byte(strings.ToLower(w)), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) RemoveUserWords(user string, words ...string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltUsersBucket).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		for _, w := range words {
+			err := b.Delete([]byte(strings.ToLower(w)))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) UserWords(user string) ([]string, error) {
+	ret := make([]string, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltUsersBucket).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			ret = append(ret, string(k))
+			return nil
+		})
+	})
+	sort.Strings(ret)
+	return ret, err
+}
+
+func (s *BoltStore) SetOverride(input string, words []string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltOverridesBucket).Put([]byte(strings.ToLower(input)), []byte(strings.Join(words, " ")))
+	})
+}
+
+func (s *BoltStore) DeleteOverride(input string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltOverridesBucket).Delete([]byte(strings.ToLower(input)))
+	})
+}
+
+func (s *BoltStore) Overrides() (map[string][]string, error) {
+	ret := make(map[string][]string)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltOverridesBucket).ForEach(func(k, v []byte) error {
+			ret[string(k)] = strings.Fields(string(v))
+			return nil
+		})
+	})
+	return ret, err
+}
+
+func (s *BoltStore) SetDictionaryEntries(entries ...DictionaryEntry) error {
+	values := make([][]byte, len(entries))
+	for i, entry := range entries {
+		v, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		values[i] = v
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltDictionaryBucket)
+		for i, entry := range entries {
+			err := bucket.Put([]byte(entry.Word), values[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) DictionaryEntries() ([]DictionaryEntry, error) {
+	ret := make([]DictionaryEntry, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDictionaryBucket).ForEach(func(k, v []byte) error {
+			entry := DictionaryEntry{}
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				return fmt.Errorf("invalid dictionary entry %s: %w", k, err)
+			}
+			ret = append(ret, entry)
+			return nil
+		})
+	})
+	return ret, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, store Store) {
+	count, err := store.AddSelection("WhoRepresents", []string{"Who", "Represents"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = store.AddSelection("whorepresents", []string{"Who", "Represents"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	_, err = store.AddSelection("whorepresents", []string{"Who", "Re", "Presents"})
+	require.NoError(t, err)
+
+	selections, err := store.SelectionCounts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]int{
+		"whorepresents": {"who represents": 2, "who re presents": 1},
+	}, selections)
+	words, err := store.WordCounts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"who": 3, "represents": 2, "re": 1, "presents": 1}, words)
+
+	require.NoError(t, store.AddUserWords("jane", "Mastodon", "fediverse", "toot"))
+	require.NoError(t, store.RemoveUserWords("jane", "toot"))
+	require.NoError(t, store.RemoveUserWords("john", "toot"))
+	userWords, err := store.UserWords("jane")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fediverse", "mastodon"}, userWords)
+	userWords, err = store.UserWords("john")
+	require.NoError(t, err)
+	assert.Empty(t, userWords)
+
+	require.NoError(t, store.SetOverride("PenIsland", []string{"Pen", "Island"}))
+	require.NoError(t, store.SetOverride("expertsexchange", []string{"Experts", "Exchange"}))
+	require.NoError(t, store.DeleteOverride("ExpertsExchange"))
+	overrides, err := store.Overrides()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"penisland": {"Pen", "Island"}}, overrides)
+
+	require.NoError(t, store.SetDictionaryEntries(
+		DictionaryEntry{Word: "toot", Status: WordAdded},
+		DictionaryEntry{Word: "fediverse", Status: WordAdded, Frequency: 10},
+	))
+	require.NoError(t, store.SetDictionaryEntries(DictionaryEntry{Word: "toot", Status: WordRemoved}))
+	entries, err := store.DictionaryEntries()
+	require.NoError(t, err)
+	assert.Equal(t, []DictionaryEntry{
+		{Word: "fediverse", Status: WordAdded, Frequency: 10},
+		{Word: "toot", Status: WordRemoved},
+	}, entries)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store, err := OpenStore("memory")
+	require.NoError(t, err)
+	testStore(t, store)
+	require.NoError(t, store.Close())
+}
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	store, err := OpenStore("bolt:" + path)
+	require.NoError(t, err)
+	testStore(t, store)
+	require.NoError(t, store.Close())
+
+	// everything survives a restart
+	store, err = OpenStore("bolt:" + path)
+	require.NoError(t, err)
+	defer store.Close()
+	words, err := store.WordCounts()
+	require.NoError(t, err)
+	assert.Equal(t, 3, words["who"])
+	overrides, err := store.Overrides()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Pen", "Island"}, overrides["penisland"])
+}
+
+func TestBoltStoreMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	store, err := OpenBoltStore(path)
+	require.NoError(t, err)
+	err = store.db.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, uint64(len(boltMigrations)), decodeCount(tx.Bucket(boltMetaBucket).Get(boltVersionKey)))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// a store of the first version gets the dictionary bucket
+	err = store.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket(boltDictionaryBucket)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(boltMetaBucket).Put(boltVersionKey, encodeCount(1))
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+	store, err = OpenBoltStore(path)
+	require.NoError(t, err)
+	entries, err := store.DictionaryEntries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// a store written by a newer version is not opened
+	err = store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMetaBucket).Put(boltVersionKey, encodeCount(uint64(len(boltMigrations)+1)))
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+	_, err = OpenBoltStore(path)
+	assert.Error(t, err)
+
+	_, err = OpenStore("postgres:localhost")
+	assert.Error(t, err)
+}
@@ -0,0 +1,269 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// The ranking formulas a Strategy can use.
+const (
+	// AverageScoreStrategy ranks hashtags by the average score of their words,
+	// which is the historical behaviour
+	AverageScoreStrategy = "average-score"
+	// FewestWordsStrategy ranks hashtags with the least capitalizations first,
+	// breaking ties on the average score of their words
+	FewestWordsStrategy = "fewest-words"
+	// MaxFrequencyStrategy ranks hashtags by their rarest word, so that a common
+	// segmentation is not outranked by one that has a single very frequent word
+	MaxFrequencyStrategy = "max-frequency"
+	// LogProbabilityStrategy ranks hashtags by the sum of the log probabilities
+	// of their words, as a unigram language model would
+	LogProbabilityStrategy = "log-probability"
+)
+
+// Strategy is a named way of scoring words and combining their scores into
+// the score of a hashtag. A nil Strategy is the average-score strategy with
+// the weights of WordScore.
+type Strategy struct {
+	Name string `json:"-"`
+	// Kind is the ranking formula, one of the *Strategy constants.
+	// It defaults to Name, so that profiles can declare variants of a formula.
+	Kind string `json:"kind,omitempty"`
+	// LengthWeight and FrequencyWeight weigh the squared length of a word and its
+	// frequency per million, see WordScore. They are not used by log-probability.
+	LengthWeight    float64 `json:"length_weight,omitempty"`
+	FrequencyWeight float64 `json:"frequency_weight,omitempty"`
+	// UnknownProbability is the probability of a word without frequency in log-probability,
+	// divided by 10 for each of its letters
+	UnknownProbability float64 `json:"unknown_probability,omitempty"`
+}
+
+const (
+	DefaultLengthWeight       = 1.0
+	DefaultFrequencyWeight    = 800.0
+	DefaultUnknownProbability = 1e-6
+)
+
+// DefaultStrategies returns the built-in strategies, with their default parameters.
+func DefaultStrategies() map[string]*Strategy {
+	return map[string]*Strategy{
+		AverageScoreStrategy: {
+			Name:            AverageScoreStrategy,
+			Kind:            AverageScoreStrategy,
+			LengthWeight:    DefaultLengthWeight,
+			FrequencyWeight: DefaultFrequencyWeight,
+		},
+		FewestWordsStrategy: {
+			Name:            FewestWordsStrategy,
+			Kind:            FewestWordsStrategy,
+			LengthWeight:    DefaultLengthWeight,
+			FrequencyWeight: DefaultFrequencyWeight,
+		},
+		MaxFrequencyStrategy: {
+			Name:            MaxFrequencyStrategy,
+			Kind:            MaxFrequencyStrategy,
+			LengthWeight:    0.1,
+			FrequencyWeight: DefaultFrequencyWeight,
+		},
+		LogProbabilityStrategy: {
+			Name:               LogProbabilityStrategy,
+			Kind:               LogProbabilityStrategy,
+			UnknownProbability: DefaultUnknownProbability,
+		},
+	}
+}
+
+func (s *Strategy) kind() string {
+	if s == nil {
+		return AverageScoreStrategy
+	}
+	return s.Kind
+}
+
+// WordScore scores a word of the lattice, higher is better.
+func (s *Strategy) WordScore(word string, frequency map[string]int) float64 {
+	if s == nil {
+		return WordScore(word, frequency)
+	}
+
+	freq := 0
+	if frequency != nil {
+		freq = frequency[word]
+	}
+
+	if s.kind() == LogProbabilityStrategy {
+		if freq > 0 {
+			return float64(freq) / 1000000.0
+		}
+		return s.UnknownProbability * math.Pow(10, -float64(len(word)))
+	}
+
+	return weightedWordScore(word, freq, s.LengthWeight, s.FrequencyWeight)
+}
+
+// Combine computes the score of a hashtag out of the scores of its words, higher is better.
+// Scores stay positive so that re-rankers and context boosts can multiply them.
+func (s *Strategy) Combine(scores []float64) float64 {
+	key := s.startKey()
+	for _, score := range scores {
+		key = s.accumulate(key, score)
+	}
+	return s.keyScore(key, len(scores))
+}
+
+// startKey and accumulate compute the scores of Combine one word at a time, as a key
+// that ranks the hashtags having the same number of words. Appending the same word to
+// two hashtags keeps their keys in the same order, so that the best hashtags of an input
+// can be built out of the best hashtags of its prefixes, see TypingSession.
+func (s *Strategy) startKey() float64 {
+	if s.kind() == MaxFrequencyStrategy {
+		return math.Inf(1)
+	}
+	return 0
+}
+
+func (s *Strategy) accumulate(key float64, score float64) float64 {
+	switch s.kind() {
+	case MaxFrequencyStrategy:
+		return math.Min(key, score)
+	case LogProbabilityStrategy:
+		return key + math.Log(score)
+	default:
+		return key + score
+	}
+}
+
+// keyScore is the score of a hashtag of words words whose key is key.
+func (s *Strategy) keyScore(key float64, words int) float64 {
+	switch s.kind() {
+	case FewestWordsStrategy:
+		// halving the score for each word keeps the hashtags ordered by their number
+		// of words, the average only breaks ties
+		mean := key / float64(words)
+		return math.Pow(2, -float64(words)) * (1 + mean/(1+mean))
+	case MaxFrequencyStrategy:
+		if words == 0 {
+			return 0
+		}
+		return key
+	case LogProbabilityStrategy:
+		// the product of the probabilities ranks like the sum of their logarithms
+		return math.Exp(key)
+	default:
+		// the average of the scores
+		return key / float64(words)
+	}
+}
+
+// ScoringProfile is the set of strategies a deployment offers, along with the one
+// used when a request doesn't name any. Without a default, the words keep the scores
+// of the match sources and are ranked by average-score.
+//
+// It is loaded from a JSON file such as:
+//
+//	{
+//	  "default": "fewest-words",
+//	  "strategies": {
+//	    "max-frequency": {"length_weight": 0.5},
+//	    "news": {"kind": "log-probability", "unknown_probability": 1e-8}
+//	  }
+//	}
+//
+// The parameters of the built-in strategies can be overridden, and new strategies
+// are declared with the kind of formula they use.
+type ScoringProfile struct {
+	Default    string               `json:"default"`
+	Strategies map[string]*Strategy `json:"strategies"`
+}
+
+// NewScoringProfile returns the profile with the built-in strategies and no default.
+func NewScoringProfile() *ScoringProfile {
+	return &ScoringProfile{
+		Strategies: DefaultStrategies(),
+	}
+}
+
+// LoadScoringProfile reads a profile file, on top of the built-in strategies.
+func LoadScoringProfile(path string) (*ScoringProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ScoringProfile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse scoring profile %s: %w", path, err)
+	}
+
+	ret := NewScoringProfile()
+	for name, s := range file.Strategies {
+		if s == nil {
+			return nil, fmt.Errorf("strategy %s of %s has no parameters", name, path)
+		}
+		base, ok := ret.Strategies[name]
+		if s.Kind == "" && !ok {
+			s.Kind = name
+		}
+		if s.Kind != "" && s.Kind != name {
+			base = ret.Strategies[s.Kind]
+		}
+		if base == nil {
+			return nil, fmt.Errorf("strategy %s of %s has unknown kind %s", name, path, s.Kind)
+		}
+		ret.Strategies[name] = base.merge(name, s)
+	}
+	if file.Default != "" {
+		if _, ok := ret.Strategies[file.Default]; !ok {
+			return nil, fmt.Errorf("unknown default strategy %s in %s", file.Default, path)
+		}
+		ret.Default = file.Default
+	}
+
+	return ret, nil
+}
+
+// merge returns a copy of s named name, with the parameters set in other.
+func (s *Strategy) merge(name string, other *Strategy) *Strategy {
+	ret := *s
+	ret.Name = name
+	if other.LengthWeight != 0 {
+		ret.LengthWeight = other.LengthWeight
+	}
+	if other.FrequencyWeight != 0 {
+		ret.FrequencyWeight = other.FrequencyWeight
+	}
+	if other.UnknownProbability != 0 {
+		ret.UnknownProbability = other.UnknownProbability
+	}
+	return &ret
+}
+
+// Strategy looks up a strategy by name, an empty name returning the default strategy,
+// which is nil if the profile has none.
+func (p *ScoringProfile) Strategy(name string) (*Strategy, error) {
+	if name == "" {
+		name = p.Default
+	}
+	if name == "" {
+		return nil, nil
+	}
+	s, ok := p.Strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %s", name)
+	}
+	return s, nil
+}
+
+// Names returns the names of the strategies of the profile, sorted.
+func (p *ScoringProfile) Names() []string {
+	ret := make([]string, 0, len(p.Strategies))
+	for name := range p.Strategies {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
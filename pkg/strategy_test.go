@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newStrategyCompleter() *Completer {
+	return NewCompleter(
+		buildWordsTrie([]string{"who", "whore", "re", "represents", "presents"}),
+		map[string]int{"who": 50000, "re": 200000, "presents": 20000, "represents": 30000, "whore": 1000},
+	)
+}
+
+func suggestTags(t *testing.T, completer *Completer, input string, strategyName string) []string {
+	strategy, err := completer.Strategy(strategyName)
+	require.NoError(t, err)
+	suggestions := completer.Suggest(input, SuggestOptions{Strategy: strategy})
+	tags := make([]string, len(suggestions.HashTags))
+	for i, ht := range suggestions.HashTags {
+		tags[i] = ht.Tag()
+	}
+	return tags
+}
+
+func TestStrategies(t *testing.T) {
+	completer := newStrategyCompleter()
+
+	assert.Equal(t, []string{"WhoRePresents", "WhoRepresents", "WhorePresents"}, suggestTags(t, completer, "whorepresents", ""))
+	assert.Equal(t, []string{"WhoRePresents", "WhoRepresents", "WhorePresents"}, suggestTags(t, completer, "whorepresents", AverageScoreStrategy))
+	assert.Equal(t, []string{"WhoRepresents", "WhorePresents", "WhoRePresents"}, suggestTags(t, completer, "whorepresents", FewestWordsStrategy))
+	assert.Equal(t, []string{"WhoRepresents", "WhoRePresents", "WhorePresents"}, suggestTags(t, completer, "whorepresents", MaxFrequencyStrategy))
+	assert.Equal(t, []string{"WhoRepresents", "WhoRePresents", "WhorePresents"}, suggestTags(t, completer, "whorepresents", LogProbabilityStrategy))
+
+	_, err := completer.Strategy("shortest")
+	assert.Error(t, err)
+}
+
+func TestStrategyScores(t *testing.T) {
+	var average *Strategy
+	assert.Equal(t, 2.0, average.Combine([]float64{1, 3}))
+	assert.Equal(t, WordScore("presents", nil), average.WordScore("presents", nil))
+
+	strategies := DefaultStrategies()
+	assert.Equal(t, 1.0, strategies[MaxFrequencyStrategy].Combine([]float64{1, 3}))
+	assert.InDelta(t, 0.03, strategies[LogProbabilityStrategy].Combine([]float64{0.1, 0.3}), 1e-9)
+	assert.InDelta(t, 1e-9, strategies[LogProbabilityStrategy].WordScore("xyz", nil), 1e-15)
+
+	// a single word beats any two words
+	fewest := strategies[FewestWordsStrategy]
+	assert.Greater(t, fewest.Combine([]float64{1}), fewest.Combine([]float64{1000, 1000}))
+	assert.Greater(t, fewest.Combine([]float64{10, 10}), fewest.Combine([]float64{1, 1}))
+}
+
+func TestLoadScoringProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.json")
+	err := os.WriteFile(path, []byte(`{
+		"default": "partner",
+		"strategies": {
+			"max-frequency": {"length_weight": 0.5},
+			"partner": {"kind": "fewest-words", "frequency_weight": 100}
+		}
+	}`), 0644)
+	require.NoError(t, err)
+
+	profile, err := LoadScoringProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{AverageScoreStrategy, FewestWordsStrategy, LogProbabilityStrategy, MaxFrequencyStrategy, "partner"}, profile.Names())
+
+	s, err := profile.Strategy("")
+	require.NoError(t, err)
+	assert.Equal(t, "partner", s.Name)
+	assert.Equal(t, FewestWordsStrategy, s.Kind)
+	assert.Equal(t, DefaultLengthWeight, s.LengthWeight)
+	assert.Equal(t, 100.0, s.FrequencyWeight)
+
+	s, err = profile.Strategy(MaxFrequencyStrategy)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, s.LengthWeight)
+	assert.Equal(t, DefaultFrequencyWeight, s.FrequencyWeight)
+
+	err = os.WriteFile(path, []byte(`{"strategies": {"partner": {"kind": "shortest"}}}`), 0644)
+	require.NoError(t, err)
+	_, err = LoadScoringProfile(path)
+	assert.Error(t, err)
+}
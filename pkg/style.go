@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed data/casing.txt
+var casingFile string
+
+// defaultCasing maps lowercase words to the casing they should always be written in.
+// It is the casing of the lexicons without one of their own, see Lexicon.Casing.
+var defaultCasing = loadCasingList(casingFile)
+
+func loadCasingList(s string) map[string]string {
+	ret := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ret[strings.ToLower(line)] = line
+	}
+	return ret
+}
+
+// Style is a way of rendering the words of a hashtag.
+type Style string
+
+const (
+	// CamelStyle is the default rendering, WorldCup2019
+	CamelStyle Style = "camel"
+	// LowerCamelStyle is worldCup2019
+	LowerCamelStyle Style = "lower-camel"
+	// SnakeStyle is world_cup_2019
+	SnakeStyle Style = "snake"
+	// KebabStyle is world-cup-2019
+	KebabStyle Style = "kebab"
+	// SpacedStyle is World Cup 2019
+	SpacedStyle Style = "spaced"
+	// LowerStyle is world cup 2019, meant for alt text
+	LowerStyle Style = "lower"
+)
+
+var AllStyles = []Style{CamelStyle, LowerCamelStyle, SnakeStyle, KebabStyle, SpacedStyle, LowerStyle}
+
+func ParseStyle(s string) (Style, error) {
+	switch Style(strings.ToLower(s)) {
+	case "", CamelStyle:
+		return CamelStyle, nil
+	case LowerCamelStyle, "lowercamel", "lower_camel":
+		return LowerCamelStyle, nil
+	case SnakeStyle:
+		return SnakeStyle, nil
+	case KebabStyle:
+		return KebabStyle, nil
+	case SpacedStyle, "title":
+		return SpacedStyle, nil
+	case LowerStyle:
+		return LowerStyle, nil
+	default:
+		return "", fmt.Errorf("unknown style %s", s)
+	}
+}
+
+// ParseStyles parses a list of styles, "all" standing for AllStyles.
+func ParseStyles(ss []string) ([]Style, error) {
+	ret := make([]Style, 0, len(ss))
+	for _, s := range ss {
+		if strings.ToLower(s) == "all" {
+			return AllStyles, nil
+		}
+		style, err := ParseStyle(s)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, style)
+	}
+	return ret, nil
+}
+
+func (s Style) joiner() string {
+	switch s {
+	case SnakeStyle:
+		return "_"
+	case KebabStyle:
+		return "-"
+	case SpacedStyle, LowerStyle:
+		return " "
+	default:
+		return ""
+	}
+}
+
+func (s Style) renderWord(word string, first bool, casing map[string]string) string {
+	if canonical, ok := casing[strings.ToLower(word)]; ok {
+		return canonical
+	}
+	switch s {
+	case LowerCamelStyle:
+		if first {
+			return strings.ToLower(word)
+		}
+		return capitalize(strings.ToLower(word))
+	case SnakeStyle, KebabStyle, LowerStyle:
+		return strings.ToLower(word)
+	default:
+		// words are already capitalized by the segmentation, and the fixed pieces
+		// of handles and URLs keep the casing of the input
+		return word
+	}
+}
+
+// Render joins the words of the hashtag in the given style, with the embedded canonical
+// casing. See RenderCasing.
+func (ht *HashTag) Render(style Style) string {
+	return ht.RenderCasing(style, nil)
+}
+
+// RenderCasing joins the words of the hashtag in the given style. Words with a canonical
+// casing in casing, such as iPhone, keep it in every style, a nil casing being the
+// embedded list (see Dictionary.Casing). The separators of handles and URLs are kept, and
+// only the words they don't separate are joined in the style.
+func (ht *HashTag) RenderCasing(style Style, casing map[string]string) string {
+	if casing == nil {
+		casing = defaultCasing
+	}
+	var sb strings.Builder
+	for i, w := range ht.Words {
+		separator := ""
+		if ht.Separators != nil {
+			separator = ht.Separators[i]
+		}
+		if separator == "" && i > 0 {
+			separator = style.joiner()
+		}
+		sb.WriteString(separator)
+		sb.WriteString(style.renderWord(w, i == 0, casing))
+	}
+	if len(ht.Separators) > len(ht.Words) {
+		sb.WriteString(ht.Separators[len(ht.Words)])
+	}
+	return sb.String()
+}
+
+// Styles renders the hashtag in each of the given styles with casing, see RenderCasing.
+func (ht *HashTag) Styles(styles []Style, casing map[string]string) map[Style]string {
+	ret := make(map[Style]string, len(styles))
+	for _, s := range styles {
+		ret[s] = ht.RenderCasing(s, casing)
+	}
+	return ret
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	ht := NewHashTag([]string{"World", "Cup", "2019"}, []float64{1, 1, 1})
+	assert.Equal(t, map[Style]string{
+		CamelStyle:      "WorldCup2019",
+		LowerCamelStyle: "worldCup2019",
+		SnakeStyle:      "world_cup_2019",
+		KebabStyle:      "world-cup-2019",
+		SpacedStyle:     "World Cup 2019",
+		LowerStyle:      "world cup 2019",
+	}, ht.Styles(AllStyles, nil))
+
+	ht = NewHashTag([]string{"Iphone", "Sale"}, []float64{1, 1})
+	assert.Equal(t, "iPhoneSale", ht.Render(CamelStyle))
+	assert.Equal(t, "iPhone_sale", ht.Render(SnakeStyle))
+	assert.Equal(t, "iPhone Sale", ht.Render(SpacedStyle))
+
+	handle := &HashTag{
+		Words:      []string{"Jane", "Doe", "1990"},
+		Scores:     []float64{1, 1, 1},
+		Separators: []string{"@", "", "_", "@mastodon.social"},
+	}
+	assert.Equal(t, "@jane-doe_1990@mastodon.social", handle.Render(KebabStyle))
+	assert.Equal(t, "@janeDoe_1990@mastodon.social", handle.Render(LowerCamelStyle))
+}
+
+func TestLexiconCasing(t *testing.T) {
+	words := []string{"iphone", "sale"}
+	lexicon := func(casing map[string]string) *Lexicon {
+		return &Lexicon{Matcher: NewAhoCorasickMatcher(buildWordsTrie(words)), Casing: casing}
+	}
+	phones := NewDictionaryCompleter(NewLexiconDictionary(lexicon(map[string]string{"iphone": "IPHONE"})))
+	plain := NewDictionaryCompleter(NewLexiconDictionary(lexicon(map[string]string{})))
+	embedded := NewDictionaryCompleter(NewLexiconDictionary(lexicon(nil)))
+
+	// each completer renders with the casing of its own lexicon
+	ht := NewHashTag([]string{"Iphone", "Sale"}, []float64{1, 1})
+	assert.Equal(t, "IPHONE_sale", ht.RenderCasing(SnakeStyle, phones.Casing()))
+	assert.Equal(t, "iphone_sale", ht.RenderCasing(SnakeStyle, plain.Casing()))
+	assert.Equal(t, "iPhone_sale", ht.RenderCasing(SnakeStyle, embedded.Casing()))
+
+	plain.Dictionary.Reload(lexicon(map[string]string{"sale": "SALE"}))
+	assert.Equal(t, map[Style]string{CamelStyle: "IphoneSALE"}, ht.Styles([]Style{CamelStyle}, plain.Casing()))
+	assert.Equal(t, "IPHONE_sale", ht.RenderCasing(SnakeStyle, phones.Casing()))
+}
+
+func TestParseStyles(t *testing.T) {
+	styles, err := ParseStyles([]string{"snake", "lowerCamel"})
+	require.NoError(t, err)
+	assert.Equal(t, []Style{SnakeStyle, LowerCamelStyle}, styles)
+
+	styles, err = ParseStyles([]string{"all"})
+	require.NoError(t, err)
+	assert.Equal(t, AllStyles, styles)
+
+	_, err = ParseStyles([]string{"shouting"})
+	assert.Error(t, err)
+}
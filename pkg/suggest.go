@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Mode selects how an input is split before being segmented.
+type Mode string
+
+const (
+	// HashTagMode segments the whole input as a single hashtag
+	HashTagMode Mode = "hashtag"
+	// HandleMode segments the local part of a user handle such as @janedoe_1990@mastodon.social,
+	// keeping its digits, underscores and domain
+	HandleMode Mode = "handle"
+	// DomainMode segments the labels of a domain name or the segments of a URL,
+	// keeping well known top level domains and subdomains as they are
+	DomainMode Mode = "domain"
+)
+
+// MaxPiecesInputLength is the longest handle or URL we are willing to segment. Each of
+// their pieces is segmented on its own, and is limited to MaxInputLength.
+const MaxPiecesInputLength = 4 * MaxInputLength
+
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(s)) {
+	case "", HashTagMode:
+		return HashTagMode, nil
+	case HandleMode:
+		return HandleMode, nil
+	case DomainMode, "url":
+		return DomainMode, nil
+	default:
+		return "", fmt.Errorf("unknown mode %s", s)
+	}
+}
+
+// normalizeMode returns the mode ParseMode parses mode as, and unknown modes as they are.
+func normalizeMode(mode Mode) Mode {
+	ret, err := ParseMode(string(mode))
+	if err != nil {
+		return mode
+	}
+	return ret
+}
+
+// SuggestOptions are the per-request options of Suggest.
+type SuggestOptions struct {
+	Mode Mode
+	// Count is the maximum number of hashtags to return, 0 means no limit
+	Count int
+	// Context is used to boost words that appear in the surrounding text, it can be nil
+	Context *Context
+	// Lattice also returns the pruned word lattice of hashtags, see Suggestions.Lattice
+	Lattice bool
+	// Strategy ranks the hashtags, nil being the average-score strategy. See Completer.Strategy.
+	Strategy *Strategy
+	// Constraints restrict the segmentations, they can be nil
+	Constraints *Constraints
+	// User adds the words of the personal dictionary of the user to the lattice,
+	// see Completer.Users
+	User string
+}
+
+// Suggestions is the result of segmenting an input, along with the debugging information
+// about how it was computed.
+type Suggestions struct {
+	Input    string
+	HashTags []*HashTag
+	// Matches are all the words found in the input, with positions relative to the input
+	Matches     []*Match
+	Adjustments []*ContextAdjustment
+	// Domain is the instance domain of a handle
+	Domain string
+	// Offset is the byte position in Input where the hashtags start, such as after
+	// the @ of a handle. See HashTag.Spans.
+	Offset int
+	// Lattice is the pruned word lattice of a hashtag, only computed when requested
+	// and not for handles or domains
+	Lattice *Lattice
+
+	MatchDuration   time.Duration
+	SuggestDuration time.Duration
+	// LexiconVersion is the version of the dictionary files the suggestions were computed with
+	LexiconVersion string
+}
+
+// Suggest segments input according to options.Mode and returns the best hashtags.
+// Inputs longer than MaxInputLength return no hashtags.
+//
+// If the completer has a cache, the suggestions may be shared with other calls and
+// must not be modified.
+func (c *Completer) Suggest(input string, options SuggestOptions) *Suggestions {
+	// the key and the suggestions must be computed with the same mode
+	options.Mode = normalizeMode(options.Mode)
+	if c.Cache != nil && c.Dictionary != nil {
+		return c.Cache.Get(c.cacheGeneration(), c.cacheKey(input, options), func() *Suggestions {
+			return c.suggest(input, options)
+		})
+	}
+	return c.suggest(input, options)
+}
+
+func newSuggestions(c *Completer, input string) *Suggestions {
+	ret := &Suggestions{
+		Input:       input,
+		HashTags:    make([]*HashTag, 0),
+		Matches:     make([]*Match, 0),
+		Adjustments: make([]*ContextAdjustment, 0),
+	}
+	if c.Dictionary != nil {
+		ret.LexiconVersion = c.Dictionary.Version()
+	}
+	return ret
+}
+
+func (c *Completer) suggest(input string, options SuggestOptions) *Suggestions {
+	ret := newSuggestions(c, input)
+
+	// cheap ass limiting
+	maxLength := MaxInputLength
+	if options.Mode == HandleMode || options.Mode == DomainMode {
+		maxLength = MaxPiecesInputLength
+	}
+	if len(input) > maxLength {
+		return ret
+	}
+
+	switch options.Mode {
+	case HandleMode:
+		start := time.Now()
+		handle := ParseHandle(input)
+		ret.Domain = handle.Domain
+		ret.Offset = handle.Offset
+		pieces, trailer := SplitPieces(handle.Local, handle.Offset)
+		ret.HashTags, ret.Matches, ret.Adjustments = c.SegmentPieces(pieces, trailer, options)
+		ret.SuggestDuration = time.Since(start)
+
+	case DomainMode:
+		start := time.Now()
+		pieces, trailer := ParseURL(input).Pieces()
+		ret.HashTags, ret.Matches, ret.Adjustments = c.SegmentPieces(pieces, trailer, options)
+		ret.SuggestDuration = time.Since(start)
+
+	default:
+		matches := c.hashTagMatches(input, options, ret)
+		start := time.Now()
+		hashTags := matches.SuggestHashtags()
+		ret.Adjustments = append(ret.Adjustments, options.Context.AdjustHashTags(hashTags)...)
+		ret.HashTags = hashTags
+		ret.SuggestDuration += time.Since(start)
+	}
+
+	ret.HashTags = c.rerank(input, ret.HashTags, options.Count)
+	return ret
+}
+
+// hashTagMatches computes the lattice of a hashtag with the constraints, the context
+// and the selections applied, and records its words and their adjustments in ret.
+func (c *Completer) hashTagMatches(input string, options SuggestOptions, ret *Suggestions) *StringMatches {
+	start := time.Now()
+	matches := c.computeMatches(input, options)
+	matches.Constrain(options.Constraints, c.Frequency())
+	ret.MatchDuration = time.Since(start)
+
+	for _, ms_ := range matches.AllMatches {
+		ret.Matches = append(ret.Matches, ms_...)
+	}
+
+	start = time.Now()
+	ret.Adjustments = matches.ApplyContext(options.Context)
+	ret.Adjustments = append(ret.Adjustments, matches.ApplyFeedback(c.Feedback)...)
+	if options.Lattice {
+		ret.Lattice = matches.Lattice()
+	}
+	ret.SuggestDuration = time.Since(start)
+	return matches
+}
+
+// rerank runs the re-rankers of the completer over hashTags and keeps the best count,
+// all of them if count is 0.
+func (c *Completer) rerank(input string, hashTags []*HashTag, count int) []*HashTag {
+	rerankers := c.Rerankers
+	if c.Feedback != nil {
+		// the selections of the users come first, so that the curated overrides still win
+		rerankers = append(RerankChain{c.Feedback}, c.Rerankers...)
+	}
+	hashTags = rerankers.Rerank(input, hashTags)
+
+	if count > 0 && len(hashTags) > count {
+		hashTags = hashTags[:count]
+	}
+	return hashTags
+}
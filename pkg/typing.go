@@ -0,0 +1,425 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// typingCandidates is the number of segmentations of each length a typing session keeps
+// for every prefix of the input, or the count of the session if it is larger. The
+// re-rankers only see the best of them, see TypingSession.candidates.
+const typingCandidates = 8
+
+// typingOverfetch multiplies the segmentations a typing session keeps when the completer
+// has re-rankers, which can promote some of the worse ones.
+const typingOverfetch = 4
+
+// TypingSession suggests hashtags for an input that is being typed, such as the text of
+// a search field sent on every keystroke.
+//
+// Instead of searching the segmentations of the whole input again, the session keeps the
+// best segmentations of each prefix of the previous input, built from the shortest prefix
+// to the longest. Typing a character only adds the segmentations ending with it, and
+// editing the input only recomputes the prefixes after the first word that changed.
+// The session starts over when the lexicon, the dictionary or the selections change.
+//
+// Only hashtags are segmented incrementally, handles and domains are passed to Suggest.
+// A session is not safe for concurrent use.
+type TypingSession struct {
+	completer *Completer
+	options   SuggestOptions
+	// count is the number of segmentations kept for each prefix and length
+	count int
+
+	generation string
+	input      string
+	last       *Suggestions
+	prefixes   prefixTable
+	// reused is the length of the prefix whose segmentations the last update reused
+	reused int
+}
+
+// NewTypingSession starts a session suggesting hashtags with options.
+func (c *Completer) NewTypingSession(options SuggestOptions) *TypingSession {
+	options.Mode = normalizeMode(options.Mode)
+	return &TypingSession{
+		completer: c,
+		options:   options,
+	}
+}
+
+// candidates returns the number of segmentations of each length to keep for the prefixes
+// of input. The re-rankers get more of them, except the overrides, which add their curated
+// segmentation themselves. The selections of input are boosted wherever Suggest ranks
+// them, so the session then keeps as many as the search of Suggest finds.
+func (s *TypingSession) candidates(input string) int {
+	c := s.completer
+	count := typingCandidates
+	if s.options.Count > count {
+		count = s.options.Count
+	}
+	if c.Feedback.Selected(input) && count < maxSearchResults {
+		return maxSearchResults
+	}
+	for _, r := range c.Rerankers {
+		if _, ok := r.(*OverrideReranker); !ok {
+			return count * typingOverfetch
+		}
+	}
+	return count
+}
+
+// Input returns the input of the last update.
+func (s *TypingSession) Input() string {
+	return s.input
+}
+
+// Reused returns the number of bytes at the start of the input whose segmentations the
+// last update took from the previous one.
+func (s *TypingSession) Reused() int {
+	return s.reused
+}
+
+// Update returns the suggestions for input, which usually extends or edits the input
+// of the previous update. Like the suggestions of Suggest, they must not be modified.
+func (s *TypingSession) Update(input string) *Suggestions {
+	c := s.completer
+	options := s.options
+	if (options.Mode != "" && options.Mode != HashTagMode) || len(input) > MaxInputLength {
+		s.reset("")
+		s.input = input
+		return c.Suggest(input, options)
+	}
+
+	generation := ""
+	if c.Dictionary != nil {
+		generation = fmt.Sprintf("%s/%d", c.cacheGeneration(), c.Feedback.Revision())
+	}
+	if generation != s.generation {
+		s.reset(generation)
+	}
+	if s.last != nil && input == s.input {
+		s.reused = len(input)
+		return s.last
+	}
+
+	// the best segmentations out of more candidates are the same, the prefixes only have
+	// to be computed again when input needs more of them
+	count := s.candidates(input)
+	if count > s.count {
+		s.prefixes.truncate(0)
+	}
+	s.count = count
+
+	ret := newSuggestions(c, input)
+	matches := c.hashTagMatches(input, options, ret)
+
+	start := time.Now()
+	s.reused = s.prefixes.update(matches, options.Strategy, s.count)
+	hashTags := s.prefixes.hashTags(options.Strategy, s.count)
+	ret.Adjustments = append(ret.Adjustments, options.Context.AdjustHashTags(hashTags)...)
+	ret.HashTags = c.rerank(input, hashTags, options.Count)
+	ret.SuggestDuration += time.Since(start)
+
+	s.input, s.last = input, ret
+	return ret
+}
+
+func (s *TypingSession) reset(generation string) {
+	s.generation = generation
+	s.input, s.last, s.reused = "", nil, 0
+	s.prefixes.truncate(0)
+}
+
+// prefixEntry is a segmentation of a prefix of the input: the words of the entry prev,
+// followed by match.
+type prefixEntry struct {
+	match *Match
+	// prev is the index of the entry of the previous words, 0 being the empty prefix
+	prev  int32
+	words int32
+	// key ranks the segmentations of a prefix that have the same number of words, see
+	// Strategy.accumulate
+	key float64
+}
+
+// prefixTable holds the best segmentations of each prefix of an input. The entries of
+// the prefixes are stored one prefix after the other, so that dropping the segmentations
+// of the longer prefixes when the input is edited only truncates the table.
+type prefixTable struct {
+	entries []prefixEntry
+	// starts[e] is where the entries of the prefix of length e start, the entries of
+	// the longest prefix ending the table
+	starts []int
+	// ending[e] are the words of the lattice ending at byte e of the input
+	ending [][]*Match
+
+	// sources, candidates and chain are scratch space to rank segmentations
+	sources    []prefixSource
+	candidates []prefixEntry
+	chain      []int32
+	// tags holds the tags of the segmentations sorted by sortByTag, tagSpans[i] is
+	// where the tag of the i-th one is
+	tags     []byte
+	tagSpans [][2]int
+}
+
+// rows returns the number of prefixes in the table, the empty prefix included.
+func (t *prefixTable) rows() int {
+	return len(t.starts) - 1
+}
+
+// row returns the segmentations of the prefix of length e.
+func (t *prefixTable) row(e int) []prefixEntry {
+	return t.entries[t.starts[e]:t.starts[e+1]]
+}
+
+// truncate keeps the segmentations of the prefixes up to length e.
+func (t *prefixTable) truncate(e int) {
+	if len(t.starts) == 0 {
+		// the empty prefix has a single segmentation without words
+		t.entries = append(t.entries, prefixEntry{prev: -1})
+		t.starts = append(t.starts, 0, 1)
+	}
+	if e+1 < t.rows() {
+		t.starts = t.starts[:e+2]
+		t.entries = t.entries[:t.starts[e+1]]
+	}
+	if e < len(t.ending) {
+		t.ending = t.ending[:e+1]
+	}
+}
+
+// update computes the segmentations of the prefixes of the lattice of sm, keeping count
+// of each length. The prefixes whose words are the same as in the previous lattice are
+// kept, update returns their length.
+func (t *prefixTable) update(sm *StringMatches, strategy *Strategy, count int) int {
+	n := len(sm.String)
+	ending := make([][]*Match, n+1)
+	for _, ms_ := range sm.AllMatches {
+		for _, m := range ms_ {
+			end := m.Pos + len(m.Match)
+			ending[end] = append(ending[end], m)
+		}
+	}
+
+	// the segmentations of a prefix only depend on the words ending in it
+	reused := 0
+	for reused < n && reused+1 < len(t.ending) && reused+1 < t.rows() &&
+		sameMatches(t.ending[reused+1], ending[reused+1]) {
+		reused++
+	}
+	t.truncate(reused)
+	t.ending = ending
+	t.entries[0].key = strategy.startKey()
+
+	for e := reused + 1; e <= n; e++ {
+		t.addRow(e, sm.MaxWords, strategy, count)
+	}
+	return reused
+}
+
+func sameMatches(a []*Match, b []*Match) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Match != b[i].Match || a[i].Pos != b[i].Pos || a[i].Score != b[i].Score {
+			return false
+		}
+	}
+	return true
+}
+
+// addRow computes the segmentations of the prefix of length e out of the ones of the
+// shorter prefixes: each word ending at e follows the segmentations of the prefix it
+// starts after.
+func (t *prefixTable) addRow(e int, maxWords int, strategy *Strategy, count int) {
+	t.sources = t.sources[:0]
+	maxPrev := int32(-1)
+	for _, m := range t.ending[e] {
+		start, end := t.starts[m.Pos], t.starts[m.Pos+1]
+		if start == end {
+			// the prefix before the word can't be segmented
+			continue
+		}
+		t.sources = append(t.sources, prefixSource{match: m, next: start, end: end})
+		if words := t.entries[end-1].words; words > maxPrev {
+			maxPrev = words
+		}
+	}
+	for words := int32(0); words <= maxPrev; words++ {
+		if maxWords > 0 && int(words) >= maxWords {
+			break
+		}
+		t.addGroup(words, strategy, count)
+	}
+	t.starts = append(t.starts, len(t.entries))
+}
+
+// prefixSource is a word ending the prefix being computed, along with the segmentations
+// of the prefix before it, entries[next:end].
+type prefixSource struct {
+	match     *Match
+	next, end int
+}
+
+// addGroup adds the best count segmentations made of a word following a segmentation of
+// words words. The segmentations of each source are sorted by descending key, so they
+// are merged.
+func (t *prefixTable) addGroup(words int32, strategy *Strategy, count int) {
+	for i := range t.sources {
+		s := &t.sources[i]
+		for s.next < s.end && t.entries[s.next].words < words {
+			s.next++
+		}
+	}
+	// nextKey returns the key of the next segmentation of source i, false if it has none left
+	nextKey := func(i int) (float64, bool) {
+		s := &t.sources[i]
+		if s.next == s.end || t.entries[s.next].words != words {
+			return 0, false
+		}
+		return strategy.accumulate(t.entries[s.next].key, s.match.Score), true
+	}
+	take := func(i int, key float64) prefixEntry {
+		s := &t.sources[i]
+		s.next++
+		return prefixEntry{match: s.match, prev: int32(s.next - 1), words: words + 1, key: key}
+	}
+
+	start := len(t.entries)
+	for len(t.entries)-start < count {
+		best, bestKey := -1, 0.0
+		for i := range t.sources {
+			if key, ok := nextKey(i); ok && (best < 0 || key > bestKey) {
+				best, bestKey = i, key
+			}
+		}
+		if best < 0 {
+			return
+		}
+		t.entries = append(t.entries, take(best, bestKey))
+	}
+
+	// the segmentations that have the same key as the last one kept are broken like
+	// SortHashTags, by descending tag
+	last := t.entries[len(t.entries)-1].key
+	t.candidates = t.candidates[:0]
+	for i := range t.sources {
+		for key, ok := nextKey(i); ok && key == last; key, ok = nextKey(i) {
+			t.candidates = append(t.candidates, take(i, key))
+		}
+	}
+	if len(t.candidates) == 0 {
+		return
+	}
+	kept := len(t.entries)
+	for kept > start && t.entries[kept-1].key == last {
+		kept--
+	}
+	tied := len(t.entries) - kept
+	t.candidates = append(t.candidates, t.entries[kept:]...)
+	t.sortByTag(t.candidates)
+	t.entries = append(t.entries[:kept], t.candidates[:tied]...)
+}
+
+// appendChain sets t.chain to the entries of the words of entry, except its last word.
+func (t *prefixTable) appendChain(entry prefixEntry) {
+	t.chain = t.chain[:0]
+	for i := entry.prev; i > 0; i = t.entries[i].prev {
+		t.chain = append(t.chain, i)
+	}
+	for i, j := 0, len(t.chain)-1; i < j; i, j = i+1, j-1 {
+		t.chain[i], t.chain[j] = t.chain[j], t.chain[i]
+	}
+}
+
+func (t *prefixTable) appendTag(b []byte, entry prefixEntry) []byte {
+	t.appendChain(entry)
+	for _, i := range t.chain {
+		b = appendCapitalized(b, t.entries[i].match.Match)
+	}
+	return appendCapitalized(b, entry.match.Match)
+}
+
+// sortByTag sorts entries by descending tag.
+func (t *prefixTable) sortByTag(entries []prefixEntry) {
+	t.tags = t.tags[:0]
+	t.tagSpans = t.tagSpans[:0]
+	for _, entry := range entries {
+		start := len(t.tags)
+		t.tags = t.appendTag(t.tags, entry)
+		t.tagSpans = append(t.tagSpans, [2]int{start, len(t.tags)})
+	}
+	sort.Sort(entriesByTag{t, entries})
+}
+
+type entriesByTag struct {
+	t       *prefixTable
+	entries []prefixEntry
+}
+
+func (e entriesByTag) Len() int {
+	return len(e.entries)
+}
+
+func (e entriesByTag) Less(i, j int) bool {
+	a, b := e.t.tagSpans[i], e.t.tagSpans[j]
+	return bytes.Compare(e.t.tags[a[0]:a[1]], e.t.tags[b[0]:b[1]]) > 0
+}
+
+func (e entriesByTag) Swap(i, j int) {
+	e.entries[i], e.entries[j] = e.entries[j], e.entries[i]
+	e.t.tagSpans[i], e.t.tagSpans[j] = e.t.tagSpans[j], e.t.tagSpans[i]
+}
+
+// hashTags returns the best count segmentations of the whole input, sorted like
+// SortHashTags. Only the returned hashtags are built.
+func (t *prefixTable) hashTags(strategy *Strategy, count int) []*HashTag {
+	n := t.rows() - 1
+	if n <= 0 {
+		return []*HashTag{}
+	}
+
+	// the candidates are ranked by the score of their hashtag, as computed by Combine
+	t.candidates = append(t.candidates[:0], t.row(n)...)
+	for i, entry := range t.candidates {
+		t.candidates[i].key = strategy.keyScore(entry.key, int(entry.words))
+	}
+	sort.Slice(t.candidates, func(i, j int) bool {
+		return t.candidates[i].key > t.candidates[j].key
+	})
+	if count > len(t.candidates) {
+		count = len(t.candidates)
+	}
+	for lo := 0; lo < count; {
+		hi := lo + 1
+		for hi < len(t.candidates) && t.candidates[hi].key == t.candidates[lo].key {
+			hi++
+		}
+		if hi-lo > 1 {
+			t.sortByTag(t.candidates[lo:hi])
+		}
+		lo = hi
+	}
+
+	ret := make([]*HashTag, count)
+	for k, entry := range t.candidates[:count] {
+		t.appendChain(entry)
+		words := make([]string, 0, entry.words)
+		scores := make([]float64, 0, entry.words)
+		for _, j := range t.chain {
+			words = append(words, capitalize(t.entries[j].match.Match))
+			scores = append(scores, t.entries[j].match.Score)
+		}
+		ret[k] = &HashTag{
+			Words:    append(words, capitalize(entry.match.Match)),
+			Scores:   append(scores, entry.match.Score),
+			Strategy: strategy,
+		}
+	}
+	return ret
+}
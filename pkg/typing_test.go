@@ -0,0 +1,180 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"testing"
+)
+
+func suggestionTags(suggestions *Suggestions) []string {
+	ret := []string{}
+	for _, ht := range suggestions.HashTags {
+		ret = append(ret, ht.Tag())
+	}
+	return ret
+}
+
+// allSuggestions returns the suggestions of input out of all its segmentations, which the
+// search of Suggest doesn't always explore.
+func allSuggestions(c *Completer, input string, options SuggestOptions) []string {
+	matches := c.hashTagMatches(input, options, newSuggestions(c, input))
+	hashTags := []*HashTag{}
+	for _, ht := range matches.ComputeHashTags(0) {
+		if options.Constraints != nil && options.Constraints.MaxWords > 0 && len(ht.Words) > options.Constraints.MaxWords {
+			continue
+		}
+		ht.Strategy = options.Strategy
+		hashTags = append(hashTags, ht)
+	}
+	options.Context.AdjustHashTags(hashTags)
+	SortHashTags(hashTags)
+	return suggestionTags(&Suggestions{HashTags: c.rerank(input, hashTags, options.Count)})
+}
+
+func TestTypingSession(t *testing.T) {
+	completer := NewCompleter(buildComplexTrie(), map[string]int{"this": 5000, "is": 8000, "clean": 300})
+	strategies := DefaultStrategies()
+	names := []string{""}
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		options := SuggestOptions{Count: 5, Strategy: strategies[name]}
+		session := completer.NewTypingSession(options)
+		input := "thisiscleaner"
+		for i := 1; i <= len(input); i++ {
+			suggestions := session.Update(input[:i])
+			assert.Equal(t, allSuggestions(completer, input[:i], options), suggestionTags(suggestions), "%s %s", name, input[:i])
+			assert.Equal(t, i-1, session.Reused())
+		}
+	}
+}
+
+func TestTypingSessionEdits(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"pen", "island", "penis", "is", "land", "lan", "i", "s"}), nil)
+	options := SuggestOptions{Count: 3}
+	session := completer.NewTypingSession(options)
+
+	check := func(input string, reused int) {
+		assert.Equal(t, allSuggestions(completer, input, options), suggestionTags(session.Update(input)), input)
+		assert.Equal(t, reused, session.Reused(), input)
+	}
+	check("penisland", 0)
+	check("penisland", 9)
+	// the segmentations of "pen" don't change, "penis" is gone
+	check("penislandis", 9)
+	check("penxsland", 3)
+	// going back only drops the longer prefixes
+	check("pen", 3)
+	check("penisland", 3)
+	assert.Empty(t, session.Update("").HashTags)
+	check("island", 0)
+}
+
+func TestTypingSessionMaxWords(t *testing.T) {
+	completer := NewCompleter(buildComplexTrie(), nil)
+	options := SuggestOptions{Count: 5, Constraints: &Constraints{MaxWords: 2}}
+	session := completer.NewTypingSession(options)
+	for _, input := range []string{"clean", "cleaner", "cleanerthis", "cleaners"} {
+		suggestions := session.Update(input)
+		assert.Equal(t, allSuggestions(completer, input, options), suggestionTags(suggestions), input)
+		for _, ht := range suggestions.HashTags {
+			assert.LessOrEqual(t, len(ht.Words), 2)
+		}
+	}
+}
+
+func TestTypingSessionInvalidation(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"fedi", "verse", "who", "whore", "represents", "presents"}), map[string]int{"whore": 100000})
+	session := completer.NewTypingSession(SuggestOptions{})
+
+	assert.Equal(t, "FediVerse", suggestionTags(session.Update("fediverse"))[0])
+	require.NoError(t, completer.Dictionary.AddWords(100, "fediverse"))
+	assert.Equal(t, "Fediverse", suggestionTags(session.Update("fediverse"))[0])
+	assert.Equal(t, 0, session.Reused())
+
+	assert.Equal(t, "WhorePresents", suggestionTags(session.Update("whorepresents"))[0])
+	session.Update("whorepresents")
+	assert.Equal(t, 13, session.Reused())
+	_, err := completer.Feedback.Select("whorepresents", []string{"Who", "Represents"})
+	require.NoError(t, err)
+	assert.Equal(t, "WhoRepresents", suggestionTags(session.Update("whorepresents"))[0])
+	assert.Equal(t, 0, session.Reused())
+}
+
+func TestTypingSessionHandles(t *testing.T) {
+	completer := NewCompleter(buildWordsTrie([]string{"jane", "doe"}), nil)
+	options := SuggestOptions{Mode: HandleMode}
+	session := completer.NewTypingSession(options)
+	assert.Equal(t, suggestionTags(completer.Suggest("@janedoe_1990", options)), suggestionTags(session.Update("@janedoe_1990")))
+}
+
+func TestTypingSessionRerankers(t *testing.T) {
+	// every split of the input in two words, the ones in the middle ranking last
+	input := "abcdefghijkl"
+	words := []string{}
+	for i := 1; i < len(input); i++ {
+		words = append(words, input[:i], input[i:])
+	}
+	completer := NewCompleter(buildWordsTrie(words), nil)
+	options := SuggestOptions{Count: 3}
+
+	check := func() {
+		session := completer.NewTypingSession(options)
+		for i := 1; i <= len(input); i++ {
+			assert.Equal(t, suggestionTags(completer.Suggest(input[:i], options)), suggestionTags(session.Update(input[:i])), input[:i])
+			// the re-rankers don't stop the session from reusing the prefixes
+			assert.Equal(t, i-1, session.Reused(), input[:i])
+		}
+	}
+	completer.Rerankers = RerankChain{NewOverrideReranker(map[string][]string{"abcdefghi": {"Abc", "Defghi"}})}
+	check()
+	completer.Rerankers = append(completer.Rerankers, NewWordReranker("boost", map[string]float64{"ghijkl": 100}), NewDiversityReranker(DefaultDiversityFactor))
+	check()
+	assert.Equal(t, "AbcdefGhijkl", suggestionTags(completer.NewTypingSession(options).Update(input))[0])
+
+	// the selections of an input widen the candidates of its updates only
+	completer.Rerankers = nil
+	_, err := completer.Feedback.Select(input, []string{"Abcde", "Fghijkl"})
+	require.NoError(t, err)
+	session := completer.NewTypingSession(options)
+	for _, prefix := range []string{input[:5], input, input[:6], input} {
+		assert.Equal(t, suggestionTags(completer.Suggest(prefix, options)), suggestionTags(session.Update(prefix)), prefix)
+	}
+	assert.Equal(t, "AbcdeFghijkl", suggestionTags(session.Update(input))[0])
+}
+
+// BenchmarkTypingSession types the HashSet inputs one character at a time, computing the
+// suggestions of each keystroke from scratch or with a typing session.
+func BenchmarkTypingSession(b *testing.B) {
+	matcher, err := BuildMatcher(DoubleArrayMatcherKind, benchmarkWords(b))
+	require.NoError(b, err)
+	dictionary := NewDictionary(buildWordsTrie(nil), nil)
+	dictionary.Reload(&Lexicon{Matcher: matcher})
+	completer := NewDictionaryCompleter(dictionary)
+	inputs := benchmarkInputs(b)
+	options := SuggestOptions{Count: 5}
+
+	b.Run("suggest", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			input := inputs[i%len(inputs)]
+			for j := 1; j <= len(input); j++ {
+				completer.Suggest(input[:j], options)
+			}
+		}
+	})
+	b.Run("session", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			input := inputs[i%len(inputs)]
+			session := completer.NewTypingSession(options)
+			for j := 1; j <= len(input); j++ {
+				session.Update(input[:j])
+			}
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"strings"
+	"sync"
+)
+
+// UserSource is the name of the source of the personal dictionaries of the users.
+const UserSource = "user"
+
+// UserDictionaries are the personal dictionaries of the users, whose words are added to
+// the lattice of the requests made for them, see SuggestOptions.User. The dictionaries
+// are persisted in a store, and kept in memory once a request needed them. They are
+// safe for concurrent use.
+type UserDictionaries struct {
+	store Store
+
+	mu      sync.Mutex
+	sources map[string]*TrieSource
+	// revision counts the changes of the dictionaries since they were loaded
+	revision uint64
+}
+
+func NewUserDictionaries(store Store) *UserDictionaries {
+	return &UserDictionaries{
+		store:   store,
+		sources: make(map[string]*TrieSource),
+	}
+}
+
+// Add adds words to the dictionary of user.
+func (u *UserDictionaries) Add(user string, words ...string) error {
+	err := u.store.AddUserWords(user, lowerWords(words)...)
+	if err != nil {
+		return err
+	}
+	u.changed(user)
+	return nil
+}
+
+// Remove removes words from the dictionary of user.
+func (u *UserDictionaries) Remove(user string, words ...string) error {
+	err := u.store.RemoveUserWords(user, lowerWords(words)...)
+	if err != nil {
+		return err
+	}
+	u.changed(user)
+	return nil
+}
+
+func (u *UserDictionaries) changed(user string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.sources, user)
+	u.revision++
+}
+
+// Words returns the dictionary of user, sorted.
+func (u *UserDictionaries) Words(user string) ([]string, error) {
+	return u.store.UserWords(user)
+}
+
+// Source returns the source of the words of user, nil if the user has none.
+func (u *UserDictionaries) Source(user string) (MatchSource, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if source, ok := u.sources[user]; ok {
+		return source, nil
+	}
+	words, err := u.store.UserWords(user)
+	if err != nil || len(words) == 0 {
+		// the users without words aren't kept, requests can name any user
+		return nil, err
+	}
+	source := NewWordListSource(UserSource, words, nil)
+	u.sources[user] = source
+	return source, nil
+}
+
+// Revision changes each time a dictionary changes.
+func (u *UserDictionaries) Revision() uint64 {
+	if u == nil {
+		return 0
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.revision
+}
+
+func lowerWords(words []string) []string {
+	ret := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w != "" {
+			ret = append(ret, w)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestUserDictionaries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	store, err := OpenBoltStore(path)
+	require.NoError(t, err)
+
+	completer := NewCompleter(buildWordsTrie([]string{"fedi", "verse", "mast", "odon"}), nil)
+	completer.Cache = NewSuggestionCache(10)
+	completer.Users = NewUserDictionaries(store)
+	options := SuggestOptions{Count: 1, User: "jane"}
+
+	assert.Equal(t, "MastOdon", completer.Suggest("mastodon", options).HashTags[0].Tag())
+	require.NoError(t, completer.Users.Add("jane", "Mastodon", " "))
+	assert.Equal(t, "Mastodon", completer.Suggest("mastodon", options).HashTags[0].Tag())
+	assert.Equal(t, UserSource, completer.Suggest("mastodon", options).Matches[0].Source)
+	// the words of a user are only used for their requests
+	assert.Equal(t, "MastOdon", completer.Suggest("mastodon", SuggestOptions{Count: 1}).HashTags[0].Tag())
+	assert.Equal(t, "MastOdon", completer.Suggest("mastodon", SuggestOptions{Count: 1, User: "john"}).HashTags[0].Tag())
+
+	// the dictionaries are persisted
+	require.NoError(t, store.Close())
+	store, err = OpenBoltStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	completer = NewCompleter(buildWordsTrie([]string{"fedi", "verse", "mast", "odon"}), nil)
+	completer.Cache = NewSuggestionCache(10)
+	completer.Users = NewUserDictionaries(store)
+	words, err := completer.Users.Words("jane")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mastodon"}, words)
+
+	session := completer.NewTypingSession(options)
+	assert.Equal(t, "Mastodon", suggestionTags(session.Update("mastodon"))[0])
+	require.NoError(t, completer.Users.Remove("jane", "mastodon"))
+	assert.Equal(t, "MastOdon", suggestionTags(session.Update("mastodon"))[0])
+	assert.Equal(t, "MastOdon", completer.Suggest("mastodon", options).HashTags[0].Tag())
+}